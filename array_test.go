@@ -0,0 +1,120 @@
+package screw_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/RainFallsSilent/screw"
+	"github.com/RainFallsSilent/screw/screwtest"
+)
+
+type arrayPeer struct {
+	Name string
+	Addr string `screw:"--addr"`
+	Port int
+}
+
+type arrayConfig struct {
+	Point  [3]int      `screw:"--point" sep:","`
+	Groups [][]string  `screw:"-g" sep:","`
+	Peers  []arrayPeer `screw:"--peer"`
+	Pair   [2]string   `screw:"args=pair"`
+}
+
+func Test_Array(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		need arrayConfig
+	}{
+		{
+			args: []string{"--point", "1,2,3", "a", "b"},
+			need: arrayConfig{Point: [3]int{1, 2, 3}, Pair: [2]string{"a", "b"}},
+		},
+		{
+			//The values of the array are filled over the occurrences
+			args: []string{"--point", "1,2", "a", "--point", "3", "b"},
+			need: arrayConfig{Point: [3]int{1, 2, 3}, Pair: [2]string{"a", "b"}},
+		},
+		{
+			args: []string{"-g", "a,b", "-g", "c", "--point", "0,0,0", "x", "y"},
+			need: arrayConfig{Groups: [][]string{{"a", "b"}, {"c"}}, Pair: [2]string{"x", "y"}},
+		},
+		{
+			//The keys are the field names or the option names, case insensitive
+			args: []string{"--peer", "name=a,ADDR=127.0.0.1,port=80", "--peer", `{"Name":"b","Port":81}`, "--point", "1,1,1", "x", "y"},
+			need: arrayConfig{
+				Point: [3]int{1, 1, 1},
+				Peers: []arrayPeer{{Name: "a", Addr: "127.0.0.1", Port: 80}, {Name: "b", Port: 81}},
+				Pair:  [2]string{"x", "y"},
+			},
+		},
+	} {
+		var got arrayConfig
+		screwtest.New(test.args...).Run(&got).NoError(t)
+		if !reflect.DeepEqual(got, test.need) {
+			t.Errorf("%q: got %+v, need %+v", test.args, got, test.need)
+		}
+	}
+}
+
+func Test_Array_Error(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		msg  string
+	}{
+		{args: []string{"--point", "1,2,3,4", "a", "b"}, msg: "--point"},
+		{args: []string{"--point", "1,2", "a", "b"}, msg: "--point"},
+		{args: []string{"--point", "1,2,3", "a"}, msg: "<pair>"},
+		{args: []string{"--point", "1,2,3", "a", "b", "c"}, msg: "<pair>"},
+		{args: []string{"--point", "1,x,3", "a", "b"}, msg: "x"},
+		{args: []string{"--peer", "host=a", "--point", "1,2,3", "a", "b"}, msg: "host"},
+		{args: []string{"--peer", "port=x", "--point", "1,2,3", "a", "b"}, msg: "x"},
+	} {
+		var got arrayConfig
+		screwtest.New(test.args...).Run(&got).ErrorContains(t, test.msg)
+	}
+}
+
+// The env value replaces the values of the array from the command line
+func Test_Array_Env(t *testing.T) {
+	type config struct {
+		Arr [2]int `screw:"--arr;env=ARR" sep:","`
+	}
+
+	var got config
+	screwtest.New("--arr", "1,2").Env(map[string]string{"ARR": "3,4"}).Run(&got).NoError(t)
+	if need := [2]int{3, 4}; got.Arr != need {
+		t.Errorf("got %v, need %v", got.Arr, need)
+	}
+}
+
+// The inner slices and the structures are split by comma without sep, in parsing and in Marshal
+func Test_Array_DefaultSep(t *testing.T) {
+	type config struct {
+		Groups [][]string  `screw:"-g"`
+		Ports  [][]int     `screw:"--ports" sep:";"`
+		Peers  []arrayPeer `screw:"--peer"`
+	}
+
+	var got config
+	screwtest.New("-g", "a,b", "-g", "c", "--ports", "1;2", "--peer", "name=a,port=1").Run(&got).NoError(t)
+	need := config{Groups: [][]string{{"a", "b"}, {"c"}}, Ports: [][]int{{1, 2}}, Peers: []arrayPeer{{Name: "a", Port: 1}}}
+	if !reflect.DeepEqual(got, need) {
+		t.Fatalf("got %+v, need %+v", got, need)
+	}
+
+	args, err := screw.Marshal(&got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var second config
+	screwtest.New(args...).Run(&second).NoError(t)
+	if !reflect.DeepEqual(second, need) {
+		t.Errorf("%q: got %+v, need %+v", args, second, need)
+	}
+
+	if _, err := screw.Marshal(&config{Groups: [][]string{{"a,b"}}}); err == nil {
+		t.Error("need an error for the value holding the separator")
+	}
+}
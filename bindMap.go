@@ -0,0 +1,291 @@
+package screw
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The key of BindMap selecting the subcommand, such as {"sub":"deploy","replicas":3}
+const SubcommandKey = "sub"
+
+// Set the structure from a map instead of the command line, such as a decoded JSON or YAML document.
+// The keys are the long, short or args names of the options, a subcommand is selected by SubcommandKey
+// or by its name with an object of its own keys. The values are converted, checked and validated like the arguments
+func (c *Screw) BindMap(m map[string]interface{}, x interface{}) error {
+	if c.version == "" {
+		c.version = defautlVersion
+	}
+
+	if err := c.register(x); err != nil {
+		return err
+	}
+
+	c.binder = newBinder(c.command, nil)
+	c.binder.exit = c.exit
+	c.binder.w = c.w
+	return c.binder.bindMap(c.structs, m)
+}
+
+// BindJSON is similar to BindMap, the map is decoded from r
+func (c *Screw) BindJSON(r io.Reader, x interface{}) error {
+	m, err := decodeJSONMap(r)
+	if err != nil {
+		return err
+	}
+	return c.BindMap(m, x)
+}
+
+// See Screw.BindMap, the structures must be pointers to the compiled types, in the same order
+func (b *Binder) BindMap(m map[string]interface{}, x ...interface{}) error {
+	roots, err := b.checkRoots(x)
+	if err != nil {
+		return err
+	}
+	return b.bindMap(roots, m)
+}
+
+// See Screw.BindJSON
+func (b *Binder) BindJSON(r io.Reader, x ...interface{}) error {
+	m, err := decodeJSONMap(r)
+	if err != nil {
+		return err
+	}
+	return b.BindMap(m, x...)
+}
+
+func decodeJSONMap(r io.Reader) (map[string]interface{}, error) {
+	var m map[string]interface{}
+	d := json.NewDecoder(r)
+	//The numbers are kept as written, so the large integers are not rounded
+	d.UseNumber()
+	if err := d.Decode(&m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (b *Binder) bindMap(roots []reflect.Value, m map[string]interface{}) error {
+	b.roots = roots
+	if err := b.setDefaults(); err != nil {
+		return err
+	}
+
+	if err := b.setMap([]*command{b.cmd}, m); err != nil {
+		return err
+	}
+
+	if err := b.checkArrays(); err != nil {
+		return err
+	}
+
+	return b.validate()
+}
+
+// Set the keys of the map, the options are looked up from the last command of the chain to the root
+func (b *Binder) setMap(chain []*command, m map[string]interface{}) error {
+	cmd := chain[len(chain)-1]
+	if v, ok := m[SubcommandKey]; ok && cmd.lookupKey(SubcommandKey) == nil {
+		name, _ := v.(string)
+		sub, ok := cmd.subcommand[name]
+		if !ok {
+			return fmt.Errorf("Unknown subcommand:%v", v)
+		}
+
+		rest := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			if k != SubcommandKey {
+				rest[k] = v
+			}
+		}
+		return b.setSubcommandMap(chain, name, sub, rest)
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var subs []string
+	for _, k := range keys {
+		if _, ok := cmd.subcommand[k]; ok && cmd.lookupKey(k) == nil {
+			subs = append(subs, k)
+			continue
+		}
+
+		var o *Option
+		for i := len(chain) - 1; i >= 0 && o == nil; i-- {
+			o = chain[i].lookupKey(k)
+		}
+		if o == nil {
+			return fmt.Errorf("error: unknown key (%s)", k)
+		}
+
+		if err := b.setMapValue(b.state(o), k, m[k]); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range subs {
+		sub, ok := toStringMap(m[name])
+		if !ok {
+			return fmt.Errorf("error: the value of the subcommand %s is not an object", name)
+		}
+		if err := b.setSubcommandMap(chain, name, cmd.subcommand[name], sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Binder) setSubcommandMap(chain []*command, name string, sub *Subcommand, m map[string]interface{}) error {
+	b.isSetSubcommand[name] = struct{}{}
+	if len(chain) == 1 {
+		b.currSubcommand = sub.command
+	}
+
+	chain = append(chain[:len(chain):len(chain)], sub.command)
+	if err := b.setMap(chain, m); err != nil {
+		return err
+	}
+
+	if sub.subMain {
+		b.fieldValue(sub.field).Addr().MethodByName(defaultSubMain).Call([]reflect.Value{})
+	}
+	return nil
+}
+
+// Find the option by its long, short or args name
+func (c *command) lookupKey(key string) *Option {
+	if o, ok := c.shortAndLong[key]; ok {
+		return o
+	}
+
+	for _, o := range c.envAndArgs {
+		if len(o.argsName) > 0 && o.argsName == key {
+			return o
+		}
+	}
+	return nil
+}
+
+// Set the value of the key, the lists and the objects are set element by element like the repeated options
+func (b *Binder) setMapValue(o *optionState, key string, v interface{}) error {
+	if v == nil {
+		return nil
+	}
+
+	var values []string
+	switch v := v.(type) {
+	case []interface{}:
+		for _, e := range v {
+			val, err := o.mapElem(e)
+			if err != nil {
+				return err
+			}
+			values = append(values, val)
+		}
+	default:
+		if m, ok := toStringMap(v); ok && o.kind() == reflect.Map {
+			keys := make([]string, 0, len(m))
+			for k := range m {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			for _, k := range keys {
+				val, err := o.mapElem(m[k])
+				if err != nil {
+					return err
+				}
+				values = append(values, k+"="+val)
+			}
+			break
+		}
+
+		val, err := o.mapElem(v)
+		if err != nil {
+			return err
+		}
+		values = append(values, val)
+	}
+
+	for _, val := range values {
+		//The elements of a list are like the repeated options
+		if err := checkOnce(key, o); err != nil {
+			return err
+		}
+
+		v := val
+		if len(o.fileName) > 0 && key == o.fileName {
+			//The value of the -file key is the file of the secret, @ in the other values is not special
+			var err error
+			if v, err = readSecret("@" + val); err != nil {
+				return err
+			}
+		}
+
+		if err := setValueAndIndex(v, o.sep, o, 0, 0); err != nil {
+			return err
+		}
+		o.setSource(SourceConfig, val, key, -1)
+	}
+	return nil
+}
+
+// Convert an element of the map to the string of one argument
+func (o *Option) mapElem(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case []interface{}:
+		//The inner slice of [][]string is split again by the separator of the elements holding several values
+		sep := multiValueSep(o.sep)
+		elems := make([]string, len(v))
+		for i, e := range v {
+			elem, err := o.mapElem(e)
+			if err != nil {
+				return "", err
+			}
+			if strings.Contains(elem, sep) {
+				return "", fmt.Errorf("error: the value (%s) of %s contains the separator %q", o.redact(elem), o.showName(), sep)
+			}
+			elems[i] = elem
+		}
+		return strings.Join(elems, sep), nil
+	}
+
+	if m, ok := toStringMap(v); ok {
+		//The element of []T of a structure
+		if data, err := json.Marshal(m); err == nil {
+			return string(data), nil
+		}
+	}
+	return fmt.Sprint(v), nil
+}
+
+// The YAML decoders can produce map[interface{}]interface{}
+func toStringMap(v interface{}) (map[string]interface{}, bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return v, true
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = e
+		}
+		return m, true
+	}
+	return nil, false
+}
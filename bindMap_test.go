@@ -0,0 +1,147 @@
+package screw
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type docDeploy struct {
+	Replicas int      `screw:"-r;--replicas" valid:"lte=10" usage:"replicas"`
+	Hosts    []string `screw:"--host" usage:"hosts"`
+}
+
+type docConfig struct {
+	Verbose bool              `screw:"-v;--verbose" usage:"verbose"`
+	Name    string            `screw:"--name" default:"app" usage:"name"`
+	Tag     string            `screw:"--tag;once" usage:"tag"`
+	Level   string            `screw:"--level" choices:"debug,info" usage:"level"`
+	Labels  map[string]string `screw:"-L" usage:"labels"`
+	ID      uint64            `screw:"--id" usage:"id"`
+	Files   []string          `screw:"args=files" usage:"files"`
+	Deploy  docDeploy         `screw:"subcommand=deploy" usage:"deploy"`
+}
+
+func Test_BindJSON(t *testing.T) {
+	for _, test := range []struct {
+		doc  string
+		need docConfig
+		sub  bool
+	}{
+		{
+			doc:  `{}`,
+			need: docConfig{Name: "app"},
+		},
+		{
+			doc:  `{"verbose":true,"name":"x","level":"info","L":{"b":"2","a":1},"id":18446744073709551615,"files":["a","b"]}`,
+			need: docConfig{Verbose: true, Name: "x", Level: "info", Labels: map[string]string{"a": "1", "b": "2"}, ID: 1<<64 - 1, Files: []string{"a", "b"}},
+		},
+		{
+			//Without sep, the comma is a part of the map value
+			doc:  `{"L":{"a":"1,2"},"files":["a,b"]}`,
+			need: docConfig{Name: "app", Labels: map[string]string{"a": "1,2"}, Files: []string{"a,b"}},
+		},
+		{
+			doc:  `{"L":"k=a,b"}`,
+			need: docConfig{Name: "app", Labels: map[string]string{"k": "a,b"}},
+		},
+		{
+			//The options of the root are looked up from the subcommand
+			doc:  `{"sub":"deploy","replicas":3,"v":true,"host":["a","b"]}`,
+			need: docConfig{Verbose: true, Name: "app", Deploy: docDeploy{Replicas: 3, Hosts: []string{"a", "b"}}},
+			sub:  true,
+		},
+		{
+			doc:  `{"verbose":true,"deploy":{"r":2}}`,
+			need: docConfig{Verbose: true, Name: "app", Deploy: docDeploy{Replicas: 2}},
+			sub:  true,
+		},
+	} {
+		var got docConfig
+		s := New(nil).SetExit(false)
+		if err := s.BindJSON(strings.NewReader(test.doc), &got); err != nil {
+			t.Fatalf("%s: %v", test.doc, err)
+		}
+
+		if !reflect.DeepEqual(got, test.need) || s.IsSetSubcommand("deploy") != test.sub {
+			t.Errorf("%s: got %+v, need %+v", test.doc, got, test.need)
+		}
+	}
+}
+
+func Test_BindJSON_Error(t *testing.T) {
+	for _, test := range []struct {
+		doc string
+		msg string
+	}{
+		{doc: `{"unknown":1}`, msg: "unknown key (unknown)"},
+		{doc: `{"tag":["a","b"]}`, msg: "tag"},
+		{doc: `{"level":"warn"}`, msg: "warn"},
+		{doc: `{"id":-1}`, msg: "-1"},
+		{doc: `{"sub":"undeploy"}`, msg: "Unknown subcommand"},
+		{doc: `{"deploy":1}`, msg: "the value of the subcommand deploy is not an object"},
+		{doc: `{"deploy":{"r":11}}`, msg: "-r;--replicas must be 10 or less"},
+		{doc: `[1]`, msg: "json"},
+	} {
+		var got docConfig
+		err := New(nil).SetExit(false).SetOutput(ioutil.Discard).BindJSON(strings.NewReader(test.doc), &got)
+		if err == nil || !strings.Contains(err.Error(), test.msg) {
+			t.Errorf("%s: got %v, need %q", test.doc, err, test.msg)
+		}
+	}
+}
+
+func Test_BindMap_Source(t *testing.T) {
+	spec := MustCompile((*docConfig)(nil))
+	b := spec.NewBinder(nil)
+	var got docConfig
+	if err := b.BindMap(map[string]interface{}{"verbose": true}, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if src := b.Source("Verbose"); src.Kind != SourceConfig || src.Name != "verbose" || src.Raw != "true" {
+		t.Errorf("got %+v", src)
+	}
+	if src := b.Source("Name"); src.Kind != SourceDefault {
+		t.Errorf("got %+v", src)
+	}
+}
+
+// The inner lists of the nested slices are set element by element
+func Test_BindJSON_Nested(t *testing.T) {
+	type config struct {
+		Groups [][]string `screw:"--groups"`
+		Ports  [][]int    `screw:"--ports" sep:";"`
+	}
+
+	var got config
+	doc := `{"groups":[["a","b"],["c"],[]],"ports":[[1,2],[3]]}`
+	if err := New(nil).SetExit(false).BindJSON(strings.NewReader(doc), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	need := [][]string{{"a", "b"}, {"c"}, nil}
+	if len(got.Groups) != len(need) {
+		t.Fatalf("got %q, need %q", got.Groups, need)
+	}
+	for i := range need {
+		if len(got.Groups[i]) != len(need[i]) {
+			t.Fatalf("group %d: got %q, need %q", i, got.Groups[i], need[i])
+		}
+		for j := range need[i] {
+			if got.Groups[i][j] != need[i][j] {
+				t.Errorf("group %d element %d: got %q, need %q", i, j, got.Groups[i][j], need[i][j])
+			}
+		}
+	}
+	if !reflect.DeepEqual(got.Ports, [][]int{{1, 2}, {3}}) {
+		t.Errorf("got %v", got.Ports)
+	}
+
+	//The element holding the separator can not be split back
+	err := New(nil).SetExit(false).SetOutput(ioutil.Discard).BindJSON(strings.NewReader(`{"groups":[["a,b"]]}`), new(config))
+	if err == nil || !strings.Contains(err.Error(), "contains the separator") {
+		t.Errorf("got %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package screw
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/antlabs/strsim"
+)
+
+const optIgnoreCase = "ignorecase"
+
+// Parse the choices tag, such as choices:"json,yaml,table" or choices:"json,yaml,table;ignorecase"
+func (o *Option) parseChoices(choices string) error {
+	if len(choices) == 0 {
+		return nil
+	}
+
+	opts := strings.Split(choices, ";")
+	for _, opt := range opts[1:] {
+		switch strings.TrimSpace(opt) {
+		case optIgnoreCase:
+			o.ignoreCase = true
+		case "":
+		default:
+			return fmt.Errorf("%s:(%s) choices(%s)", ErrUnsupported, opt, choices)
+		}
+	}
+
+	for _, choice := range strings.Split(opts[0], ",") {
+		if choice = strings.TrimSpace(choice); len(choice) > 0 {
+			o.choices = append(o.choices, choice)
+		}
+	}
+	return nil
+}
+
+// Check that the value is one of the choices, every element is checked if the slice value is split by sep.
+// When the case is ignored, the value is replaced with the spelling of the choice
+func (o *Option) checkChoices(val string, sep string) (string, error) {
+	if len(o.choices) == 0 {
+		return val, nil
+	}
+
+	if (o.kind() == reflect.Slice || o.kind() == reflect.Array) && len(sep) > 0 {
+		var err error
+		elems := strings.Split(val, sep)
+		for i, elem := range elems {
+			if elems[i], err = o.checkChoice(elem); err != nil {
+				return "", err
+			}
+		}
+		return strings.Join(elems, sep), nil
+	}
+
+	return o.checkChoice(val)
+}
+
+func (o *Option) checkChoice(val string) (string, error) {
+	for _, choice := range o.choices {
+		if choice == val || o.ignoreCase && strings.EqualFold(choice, val) {
+			return choice, nil
+		}
+	}
+
+	m := fmt.Sprintf("error: invalid value '%s' for %s, expected one of %s", val, o.showName(), strings.Join(o.choices, ", "))
+
+	var opts []strsim.Option
+	if o.ignoreCase {
+		opts = append(opts, strsim.IgnoreCase())
+	}
+	if s := strsim.FindBestMatchOne(val, o.choices, opts...); s.Score > 0.0 {
+		m += fmt.Sprintf("\n	Did you mean '%s'?\n", s.S)
+	}
+	return "", errors.New(m)
+}
+
+// The name of the option in the messages, the long option is preferred
+func (o *Option) showName() string {
+	switch {
+	case len(o.showLong) > 0:
+		return "--" + o.showLong[0]
+	case len(o.showShort) > 0:
+		return "-" + o.showShort[0]
+	case len(o.argsName) > 0:
+		return "<" + o.argsName + ">"
+	}
+	return o.envName
+}
@@ -0,0 +1,114 @@
+package screw
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type choicesConfig struct {
+	Format string   `screw:"-f;--format" choices:"json,yaml,table;ignorecase" default:"table" usage:"output format"`
+	Level  string   `screw:"--level;env=LEVEL" choices:"debug,info" usage:"level"`
+	Tags   []string `screw:"--tag" sep:"," choices:"a,b,c" usage:"tags"`
+	Files  []string `screw:"args=files" choices:"x,y" usage:"files"`
+}
+
+func Test_Choices(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		env  []string
+		need choicesConfig
+	}{
+		{
+			need: choicesConfig{Format: "table"},
+		},
+		{
+			//The spelling of the choice is kept when the case is ignored
+			args: []string{"-f", "JSON", "--level", "info", "--tag", "a,c", "--tag", "b", "x", "y", "x"},
+			need: choicesConfig{Format: "json", Level: "info", Tags: []string{"a", "c", "b"}, Files: []string{"x", "y", "x"}},
+		},
+		{
+			env:  []string{"LEVEL=debug"},
+			need: choicesConfig{Format: "table", Level: "debug"},
+		},
+	} {
+		var got choicesConfig
+		spec := MustCompile((*choicesConfig)(nil))
+		if err := spec.NewBinder(test.args).SetEnviron(test.env).Bind(&got); err != nil {
+			t.Fatalf("%q: %v", test.args, err)
+		}
+
+		if !reflect.DeepEqual(got, test.need) {
+			t.Errorf("%q: got %+v, need %+v", test.args, got, test.need)
+		}
+	}
+}
+
+func Test_Choices_Error(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		env  []string
+		msg  []string
+	}{
+		{
+			args: []string{"-f", "jsn"},
+			msg:  []string{"invalid value 'jsn' for --format, expected one of json, yaml, table", "Did you mean 'json'?"},
+		},
+		{
+			args: []string{"--level", "Info"},
+			msg:  []string{"invalid value 'Info' for --level"},
+		},
+		{
+			args: []string{"--tag", "a,d"},
+			msg:  []string{"invalid value 'd' for --tag"},
+		},
+		{
+			env: []string{"LEVEL=trace"},
+			msg: []string{"invalid value 'trace' for --level"},
+		},
+		{
+			args: []string{"x", "z", "w"},
+			msg:  []string{"invalid value 'z' for <files>, expected one of x, y"},
+		},
+	} {
+		spec := MustCompile((*choicesConfig)(nil))
+		err := spec.NewBinder(test.args).SetEnviron(test.env).Bind(new(choicesConfig))
+		if err == nil {
+			t.Errorf("%q %q: no error", test.args, test.env)
+			continue
+		}
+
+		for _, msg := range test.msg {
+			if !strings.Contains(err.Error(), msg) {
+				t.Errorf("%q %q: %q does not contain %q", test.args, test.env, err, msg)
+			}
+		}
+	}
+}
+
+func Test_Choices_Tag(t *testing.T) {
+	type badDefault struct {
+		Format string `screw:"--format" choices:"json,yaml" default:"xml" usage:"format"`
+	}
+	if _, err := Compile((*badDefault)(nil)); err == nil {
+		t.Error("no error for the default value that is not a choice")
+	}
+
+	type badOption struct {
+		Format string `screw:"--format" choices:"json,yaml;nocase" usage:"format"`
+	}
+	if _, err := Compile((*badOption)(nil)); err == nil {
+		t.Error("no error for the unknown option of the choices")
+	}
+}
+
+func Test_Choices_Help(t *testing.T) {
+	var out bytes.Buffer
+	MustCompile((*choicesConfig)(nil)).Usage(&out)
+	for _, s := range []string{"json, yaml, table", "debug, info", "x, y"} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("the help does not contain %q:\n%s", s, out.String())
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// screw-gen generates a ParseXxx(args []string) (*Xxx, error) function for the tagged structures of a package.
+// The generated parser has the same semantics as screw.Bind without reflection, it is used with go:generate
+//
+//	//go:generate screw-gen -t Options
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/RainFallsSilent/screw"
+)
+
+type gen struct {
+	Types  []string `screw:"-t;--type" sep:"," usage:"the names of the structures, separated by comma"`
+	Output string   `screw:"-o;--output" usage:"the output file, the default is <type>_screw.go"`
+	Dir    string   `screw:"args=dir" usage:"the package directory, the default is the current directory"`
+}
+
+func main() {
+	var g gen
+	screw.SetAbout("Generate the parsers of the tagged structures without reflection")
+	screw.MustBind(&g)
+
+	if err := run(&g); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(g *gen) error {
+	if len(g.Types) == 0 {
+		return fmt.Errorf("error: no type is given, use -t")
+	}
+
+	if len(g.Dir) == 0 {
+		g.Dir = "."
+	}
+
+	code, err := screw.NewStaticGen(g.Dir).Type(g.Types...).Generate()
+	if err != nil {
+		return err
+	}
+
+	if len(g.Output) == 0 {
+		g.Output = filepath.Join(g.Dir, strings.ToLower(g.Types[0])+"_screw.go")
+	}
+	return ioutil.WriteFile(g.Output, code, 0644)
+}
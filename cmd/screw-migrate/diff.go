@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// The number of unchanged lines around the changes
+const diffContext = 3
+
+// The operation of one line, ' ' keeps, '-' deletes and '+' inserts
+type diffLine struct {
+	op   byte
+	text string
+}
+
+func splitLines(b []byte) []string {
+	lines := strings.SplitAfter(string(b), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Compute the line operations from the longest common subsequence
+func diffLines(a, b []string) []diffLine {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case j == len(b) || i < len(a) && lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	return lines
+}
+
+// Generate the unified diff of the file
+func unifiedDiff(name string, src, dst []byte) []byte {
+	lines := diffLines(splitLines(src), splitLines(dst))
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "--- %s.orig\n+++ %s\n", name, name)
+
+	for start := 0; start < len(lines); {
+		//Find the next change
+		for start < len(lines) && lines[start].op == ' ' {
+			start++
+		}
+		if start == len(lines) {
+			break
+		}
+
+		//The hunk ends when there are more unchanged lines than the context of two hunks
+		end := start
+		for end < len(lines) {
+			same := end
+			for same < len(lines) && lines[same].op == ' ' {
+				same++
+			}
+			if same == len(lines) || same-end > 2*diffContext {
+				break
+			}
+			end = same + 1
+		}
+
+		first := start - diffContext
+		if first < 0 {
+			first = 0
+		}
+		last := end + diffContext
+		if last > len(lines) {
+			last = len(lines)
+		}
+
+		//The line numbers of the hunk in both files
+		srcLine, dstLine := 1, 1
+		for _, l := range lines[:first] {
+			if l.op != '+' {
+				srcLine++
+			}
+			if l.op != '-' {
+				dstLine++
+			}
+		}
+
+		srcNum, dstNum := 0, 0
+		for _, l := range lines[first:last] {
+			if l.op != '+' {
+				srcNum++
+			}
+			if l.op != '-' {
+				dstNum++
+			}
+		}
+
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", srcLine, srcNum, dstLine, dstNum)
+		for _, l := range lines[first:last] {
+			out.WriteByte(l.op)
+			out.WriteString(l.text)
+			if !strings.HasSuffix(l.text, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		start = last
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,56 @@
+// screw-migrate rewrites the flag code of packages to screw.
+// It prints the diff of the changes, or writes the files with -w
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/RainFallsSilent/screw"
+)
+
+type migrate struct {
+	Write    bool     `screw:"-w;--write" usage:"write the result to the source files instead of printing the diff"`
+	TypeName string   `screw:"-t;--type" default:"config" usage:"the name of the generated struct"`
+	VarName  string   `screw:"--var" default:"cfg" usage:"the name of the generated variable"`
+	Dirs     []string `screw:"args=dir" usage:"the package directories, the default is the current directory"`
+}
+
+func main() {
+	var m migrate
+	screw.SetAbout("Rewrite the flag code of the packages to screw")
+	screw.MustBind(&m)
+
+	if len(m.Dirs) == 0 {
+		m.Dirs = []string{"."}
+	}
+
+	code := 0
+	for _, dir := range m.Dirs {
+		if err := run(&m, dir); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
+
+func run(m *migrate, dir string) error {
+	files, err := screw.NewMigrate(dir).SetTypeName(m.TypeName).SetVarName(m.VarName).Run()
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		if m.Write {
+			if err := ioutil.WriteFile(f.Name, f.Dst, 0644); err != nil {
+				return err
+			}
+			continue
+		}
+
+		os.Stdout.Write(unifiedDiff(f.Name, f.Src, f.Dst))
+	}
+	return nil
+}
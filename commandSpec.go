@@ -0,0 +1,318 @@
+package screw
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// CommandSpec describes a command and its subcommands, it can be serialized to JSON
+type CommandSpec struct {
+	Name        string        `json:"name"`
+	Usage       string        `json:"usage,omitempty"`
+	About       string        `json:"about,omitempty"`
+	Version     string        `json:"version,omitempty"`
+	Options     []OptionSpec  `json:"options,omitempty"`
+	Positionals []OptionSpec  `json:"positionals,omitempty"`
+	Subcommands []CommandSpec `json:"subcommands,omitempty"`
+}
+
+// OptionSpec describes an option, an env or a positional argument
+type OptionSpec struct {
+	//The Go field name, such as Serve.Port
+	Field string   `json:"field"`
+	Short []string `json:"short,omitempty"`
+	Long  []string `json:"long,omitempty"`
+	Env   string   `json:"env,omitempty"`
+	//The args name of the positional argument
+	Args     string   `json:"args,omitempty"`
+	Type     string   `json:"type"`
+	Usage    string   `json:"usage,omitempty"`
+	Default  string   `json:"default,omitempty"`
+	Sep      string   `json:"sep,omitempty"`
+	Choices  []string `json:"choices,omitempty"`
+	Required bool     `json:"required,omitempty"`
+	Hidden   bool     `json:"hidden,omitempty"`
+	Greedy   bool     `json:"greedy,omitempty"`
+	Once     bool     `json:"once,omitempty"`
+	Secret   bool     `json:"secret,omitempty"`
+}
+
+// Describe the registered structures, it is built from the same options as the help message
+func (c *Screw) Spec() CommandSpec {
+	return c.command.spec(c.procName, "")
+}
+
+// Describe the compiled structures, see Screw.Spec
+func (s *Spec) CommandSpec() CommandSpec {
+	return s.cmd.spec(s.cmd.procName, "")
+}
+
+func (c *command) spec(name string, usage string) CommandSpec {
+	s := CommandSpec{Name: name, Usage: usage, About: c.about, Version: c.version}
+	for _, o := range c.options() {
+		if len(o.argsName) > 0 {
+			s.Positionals = append(s.Positionals, c.optionSpec(o))
+			continue
+		}
+		s.Options = append(s.Options, c.optionSpec(o))
+	}
+
+	names := make([]string, 0, len(c.subcommand))
+	for name := range c.subcommand {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		sub := c.subcommand[name]
+		s.Subcommands = append(s.Subcommands, sub.spec(name, sub.usage))
+	}
+	return s
+}
+
+func (c *command) optionSpec(o *Option) OptionSpec {
+	s := OptionSpec{
+		Field:    c.getRoot().fieldName(o.field),
+		Short:    o.showShort,
+		Long:     o.showLong,
+		Env:      o.envName,
+		Args:     o.argsName,
+		Type:     o.typ.String(),
+		Usage:    o.usage,
+		Default:  o.redact(o.showDefValue),
+		Sep:      o.sep,
+		Choices:  o.choices,
+		Required: o.required,
+		Hidden:   o.hidden,
+		Greedy:   o.greedy,
+		Once:     o.once,
+		Secret:   o.secret,
+	}
+
+	if len(o.fileName) > 0 {
+		s.Long = append(s.Long[:len(s.Long):len(s.Long)], o.fileName)
+	}
+	return s
+}
+
+// Generate the JSON schema of the documents of BindMap and BindJSON.
+// The properties are the keys of BindMap: the long, short or args names of the options.
+// A subcommand is the sub property naming it, or the property of its name with an object of its own keys
+func (c *Screw) JSONSchema() ([]byte, error) {
+	return c.command.jsonSchemaDocument()
+}
+
+// See Screw.JSONSchema
+func (s *Spec) JSONSchema() ([]byte, error) {
+	return s.cmd.jsonSchemaDocument()
+}
+
+// The schema of the root command with the draft and the description
+func (c *command) jsonSchemaDocument() ([]byte, error) {
+	if len(c.types) == 0 {
+		return nil, fmt.Errorf("%w: no structure is registered", ErrUnsupportedType)
+	}
+
+	root := c.jsonSchema()
+	root["$schema"] = jsonSchemaDraft
+	if len(c.about) > 0 {
+		root["description"] = c.about
+	}
+
+	return json.MarshalIndent(root, "", "  ")
+}
+
+// The schema of the keys of the command level, the env only options can not be set by BindMap
+func (c *command) jsonSchema() jsonSchema {
+	s := newObjectSchema()
+	for _, o := range c.options() {
+		name := o.mapKey()
+		if len(name) == 0 {
+			continue
+		}
+
+		s.properties()[name] = optionSchema(o)
+		if o.required {
+			s["required"] = append(s["required"].([]string), name)
+		}
+		if len(o.fileName) > 0 {
+			s.properties()[o.fileName] = jsonSchema{"type": "string", "description": "the file of the value of " + name}
+		}
+	}
+
+	names := make([]string, 0, len(c.subcommand))
+	for name := range c.subcommand {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(names) > 0 && c.lookupKey(SubcommandKey) == nil {
+		s.properties()[SubcommandKey] = jsonSchema{"type": "string", "enum": names}
+	}
+	for _, name := range names {
+		sub := c.subcommand[name]
+		subSchema := sub.jsonSchema()
+		if len(sub.usage) > 0 {
+			subSchema["description"] = sub.usage
+		}
+		s.properties()[name] = subSchema
+	}
+	return s
+}
+
+// The key of the option in BindMap, the first long name, the short name or the args name
+func (o *Option) mapKey() string {
+	switch {
+	case len(o.showLong) > 0:
+		return o.showLong[0]
+	case len(o.showShort) > 0:
+		return o.showShort[0]
+	}
+	return o.argsName
+}
+
+type jsonSchema map[string]interface{}
+
+func newObjectSchema() jsonSchema {
+	return jsonSchema{"type": "object", "properties": map[string]interface{}{}, "required": []string{}}
+}
+
+func (s jsonSchema) properties() map[string]interface{} {
+	return s["properties"].(map[string]interface{})
+}
+
+// MarshalJSON omits the empty required list
+func (s jsonSchema) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(s))
+	for k, v := range s {
+		if required, ok := v.([]string); ok && len(required) == 0 {
+			continue
+		}
+		m[k] = v
+	}
+	return json.Marshal(m)
+}
+
+func optionSchema(o *Option) jsonSchema {
+	s := typeSchema(o.typ)
+	if len(o.usage) > 0 {
+		s["description"] = o.usage
+	}
+
+	if len(o.choices) > 0 {
+		enum := o.choices
+		if items, ok := s["items"].(jsonSchema); ok {
+			items["enum"] = enum
+		} else {
+			s["enum"] = enum
+		}
+	}
+
+	if o.secret {
+		s["writeOnly"] = true
+		s["format"] = "password"
+		return s
+	}
+
+	if len(o.showDefValue) > 0 {
+		v := reflect.New(o.typ).Elem()
+		if err := setDefaultValue(o.showDefValue, o.sep, o.layout, v); err == nil {
+			s["default"] = schemaValue(v)
+		}
+	}
+	return s
+}
+
+// The types that parse themselves from a string are strings in the schema
+func isStringType(t reflect.Type) bool {
+	return t.Kind() == reflect.String || t == durationType || t == timeType || isSelfParsing(t)
+}
+
+func typeSchema(t reflect.Type) jsonSchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if isStringType(t) {
+		s := jsonSchema{"type": "string"}
+		switch t {
+		case durationType:
+			s["format"] = "duration"
+		case timeType:
+			s["format"] = "date-time"
+		}
+		return s
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return jsonSchema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return jsonSchema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return jsonSchema{"type": "number"}
+	case reflect.Slice:
+		return jsonSchema{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Array:
+		return jsonSchema{"type": "array", "items": typeSchema(t.Elem()), "minItems": t.Len(), "maxItems": t.Len()}
+	case reflect.Map:
+		return jsonSchema{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		s := newObjectSchema()
+		for i := 0; i < t.NumField(); i++ {
+			if sf := t.Field(i); sf.PkgPath == "" {
+				s.properties()[sf.Name] = typeSchema(sf.Type)
+			}
+		}
+		return s
+	}
+	return jsonSchema{}
+}
+
+// The JSON value of the default value, in the form of its schema
+func schemaValue(v reflect.Value) interface{} {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	t := v.Type()
+	if isStringType(t) {
+		if t.Kind() == reflect.String {
+			return v.String()
+		}
+		if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+			if text, err := m.MarshalText(); err == nil {
+				return string(text)
+			}
+		}
+		return fmt.Sprint(v.Interface())
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = schemaValue(v.Index(i))
+		}
+		return values
+	case reflect.Map:
+		values := make(map[string]interface{}, v.Len())
+		for _, k := range v.MapKeys() {
+			values[fmt.Sprint(k.Interface())] = schemaValue(v.MapIndex(k))
+		}
+		return values
+	}
+	return v.Interface()
+}
@@ -0,0 +1,180 @@
+package screw
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type schemaServe struct {
+	Port int           `screw:"-p;--port" default:"8080" usage:"port" valid:"required"`
+	Wait time.Duration `screw:"--wait" usage:"wait"`
+}
+
+type schemaTLS struct {
+	Cert string `screw:"--cert" usage:"cert"`
+}
+
+type schemaConfig struct {
+	Verbose bool        `screw:"-v" usage:"verbose"`
+	Level   string      `screw:"--level;--log-level" choices:"debug,info" usage:"level"`
+	Token   string      `screw:"--token;secret" usage:"token"`
+	Home    string      `screw:"env=HOME" usage:"home"`
+	Up      schemaTLS   `prefix:"up-"`
+	Serve   schemaServe `screw:"subcommand=serve" usage:"serve"`
+	Files   []string    `screw:"args=files" usage:"files"`
+}
+
+func Test_JSONSchema(t *testing.T) {
+	s := New(nil).SetAbout("the app")
+	if err := s.Register(new(schemaConfig)); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	need := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "description": "the app",
+  "type": "object",
+  "properties": {
+    "v": {"type": "boolean", "description": "verbose"},
+    "level": {"type": "string", "description": "level", "enum": ["debug", "info"]},
+    "token": {"type": "string", "description": "token", "writeOnly": true, "format": "password"},
+    "token-file": {"type": "string", "description": "the file of the value of token"},
+    "up-cert": {"type": "string", "description": "cert"},
+    "files": {"type": "array", "items": {"type": "string"}, "description": "files"},
+    "sub": {"type": "string", "enum": ["serve"]},
+    "serve": {
+      "type": "object",
+      "description": "serve",
+      "properties": {
+        "port": {"type": "integer", "description": "port", "default": 8080},
+        "wait": {"type": "string", "format": "duration", "description": "wait"}
+      },
+      "required": ["port"]
+    }
+  }
+}`
+	var gotValue, needValue interface{}
+	if err := json.Unmarshal(got, &gotValue); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(need), &needValue); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(gotValue, needValue) {
+		t.Errorf("got\n%s\nneed\n%s", got, need)
+	}
+}
+
+// Every key of the schema is accepted by BindMap
+func Test_JSONSchema_BindMap(t *testing.T) {
+	s := New(nil)
+	if err := s.Register(new(schemaConfig)); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := s.JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := writeFiles(t, map[string]string{"tok": "x"})
+	var check func(path []string, schema map[string]interface{})
+	check = func(path []string, schema map[string]interface{}) {
+		for key, p := range schema["properties"].(map[string]interface{}) {
+			p := p.(map[string]interface{})
+			if p["type"] == "object" && len(path) == 0 && key != SubcommandKey {
+				if _, ok := p["properties"]; ok {
+					check(append(path, key), p)
+					continue
+				}
+			}
+
+			var doc map[string]interface{}
+			switch {
+			case key == SubcommandKey:
+				doc = map[string]interface{}{key: p["enum"].([]interface{})[0], "port": 1}
+			case strings.HasSuffix(key, "-file"):
+				doc = map[string]interface{}{key: dir + "/tok"}
+			default:
+				doc = map[string]interface{}{key: schemaSample(p)}
+			}
+			//The required options of the subcommands are set
+			for i := len(path) - 1; i >= 0; i-- {
+				doc = map[string]interface{}{path[i]: doc}
+				if key != "port" {
+					doc[path[i]].(map[string]interface{})["port"] = 1
+				}
+			}
+
+			if err := New(nil).SetExit(false).SetOutput(ioutil.Discard).BindMap(doc, new(schemaConfig)); err != nil {
+				t.Errorf("%v: %v", doc, err)
+			}
+		}
+	}
+	check(nil, schema)
+}
+
+// A value of the schema of the option
+func schemaSample(p map[string]interface{}) interface{} {
+	if enum, ok := p["enum"].([]interface{}); ok {
+		return enum[0]
+	}
+
+	switch p["type"] {
+	case "boolean":
+		return true
+	case "integer", "number":
+		return 1
+	case "array":
+		return []interface{}{schemaSample(p["items"].(map[string]interface{}))}
+	}
+	if p["format"] == "duration" {
+		return "1s"
+	}
+	return "x"
+}
+
+// The compiled Spec describes the same command as the Screw it is compiled from
+func Test_CommandSpec_Compiled(t *testing.T) {
+	s := New(nil).SetProcName("app").SetAbout("the app").SetVersion("v2")
+	if err := s.Register(new(schemaConfig)); err != nil {
+		t.Fatal(err)
+	}
+
+	spec, err := New(nil).SetProcName("app").SetAbout("the app").SetVersion("v2").Compile((*schemaConfig)(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	need := s.Spec()
+	if got := spec.CommandSpec(); !reflect.DeepEqual(got, need) {
+		t.Errorf("got %+v, need %+v", got, need)
+	}
+	if need.Name != "app" || len(need.Subcommands) != 1 || need.Subcommands[0].Name != "serve" {
+		t.Errorf("got %+v", need)
+	}
+
+	needSchema, err := s.JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotSchema, err := spec.JSONSchema()
+	if err != nil || string(gotSchema) != string(needSchema) {
+		t.Errorf("got %s %v, need %s", gotSchema, err, needSchema)
+	}
+}
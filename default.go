@@ -14,11 +14,21 @@ func isDefvalJSON(def []byte) bool {
 	return false
 }
 
-func setDefaultValue(def string, v reflect.Value) error {
+func setDefaultValue(def string, sep string, layout string, v reflect.Value) error {
 	def2 := StringToBytes(def)
 	if isDefvalJSON(def2) {
-		return json.Unmarshal(def2, v.Addr().Interface())
+		err := json.Unmarshal(def2, v.Addr().Interface())
+		if err != nil {
+			if ok, err2 := setJSONStrings(def2, layout, v); ok {
+				return err2
+			}
+		}
+		return err
 	}
 
-	return setBase(def, v)
+	//The default of a map without sep is split strictly by comma, like the env values
+	if len(sep) == 0 && v.Kind() == reflect.Map {
+		sep = defaultSep
+	}
+	return setLayoutValue(def, sep, layout, v)
 }
@@ -0,0 +1,213 @@
+package screw
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type envSub struct {
+	Port int `screw:"--port" usage:"port"`
+}
+
+type envConfig struct {
+	ListenAddr string `screw:"-l;long" usage:"listen address"`
+	Debug      bool   `screw:"-d;env" usage:"debug"`
+	Token      string `screw:"env=TOKEN" usage:"token"`
+	Sub        envSub `screw:"subcommand=sub" usage:"sub"`
+}
+
+func Test_EnvName(t *testing.T) {
+	for _, test := range []struct {
+		prefix string
+		auto   bool
+		names  map[string]string
+	}{
+		{
+			names: map[string]string{"Debug": "DEBUG", "Token": "TOKEN"},
+		},
+		{
+			prefix: "MYAPP",
+			names:  map[string]string{"Debug": "MYAPP_DEBUG", "Token": "TOKEN"},
+		},
+		{
+			prefix: "MYAPP_",
+			auto:   true,
+			names:  map[string]string{"ListenAddr": "MYAPP_LISTEN_ADDR", "Debug": "MYAPP_DEBUG", "Token": "TOKEN", "Sub.Port": "MYAPP_SUB_PORT"},
+		},
+		{
+			auto:  true,
+			names: map[string]string{"ListenAddr": "LISTEN_ADDR", "Debug": "DEBUG", "Token": "TOKEN", "Sub.Port": "SUB_PORT"},
+		},
+	} {
+		s := New(nil).SetEnvPrefix(test.prefix).SetAutoEnv(test.auto)
+		if err := s.Register(new(envConfig)); err != nil {
+			t.Fatal(err)
+		}
+
+		got := map[string]string{}
+		for _, o := range s.allOptions() {
+			if len(o.envName) > 0 {
+				got[s.fieldName(o.field)] = o.envName
+			}
+		}
+
+		if !reflect.DeepEqual(got, test.names) {
+			t.Errorf("prefix %q auto %t: got %v, need %v", test.prefix, test.auto, got, test.names)
+		}
+	}
+}
+
+func Test_Env_Bind(t *testing.T) {
+	env := []string{"MYAPP_LISTEN_ADDR=:8080", "MYAPP_DEBUG=yes", "MYAPP_SUB_PORT=81"}
+	for _, test := range []struct {
+		args []string
+		env  []string
+		need envConfig
+	}{
+		{
+			args: []string{"sub"},
+			env:  env,
+			need: envConfig{ListenAddr: ":8080", Debug: true, Sub: envSub{Port: 81}},
+		},
+		{
+			//The env takes precedence over the command line, as with the env tag
+			args: []string{"-l", ":9090", "sub", "--port", "82"},
+			env:  env,
+			need: envConfig{ListenAddr: ":8080", Debug: true, Sub: envSub{Port: 81}},
+		},
+		{
+			args: []string{"-l", ":9090", "sub", "--port", "82"},
+			env:  []string{"MYAPP_DEBUG=1"},
+			need: envConfig{ListenAddr: ":9090", Debug: true, Sub: envSub{Port: 82}},
+		},
+		{
+			args: nil,
+			env:  []string{"MYAPP_DEBUG=false", "TOKEN=x", "LISTEN_ADDR=:1"},
+			need: envConfig{Token: "x"},
+		},
+	} {
+		var got envConfig
+		s := New(test.args).SetExit(false).SetEnvPrefix("MYAPP").SetAutoEnv(true).SetEnviron(test.env)
+		if err := s.Bind(&got); err != nil {
+			t.Fatalf("%q: %v", test.args, err)
+		}
+
+		if !reflect.DeepEqual(got, test.need) {
+			t.Errorf("%q %q: got %+v, need %+v", test.args, test.env, got, test.need)
+		}
+	}
+}
+
+// The package level settings are kept by the package level Bind
+func Test_Env_PackageBind(t *testing.T) {
+	oldArgs, oldCommandLine := os.Args, CommandLine
+	defer func() {
+		os.Args, CommandLine = oldArgs, oldCommandLine
+	}()
+
+	os.Args = []string{"app", "sub"}
+	CommandLine = New(nil)
+	SetEnvPrefix("MYAPP")
+	SetAutoEnv(true)
+	SetEnv(Environ([]string{"MYAPP_LISTEN_ADDR=:8080", "MYAPP_SUB_PORT=81"}))
+
+	var got envConfig
+	if err := Bind(&got, "v1", "about"); err != nil {
+		t.Fatal(err)
+	}
+
+	need := envConfig{ListenAddr: ":8080", Sub: envSub{Port: 81}}
+	if !reflect.DeepEqual(got, need) || !IsSetSubcommand("sub") {
+		t.Errorf("got %+v, need %+v", got, need)
+	}
+	if CommandLine.version != "v1" || CommandLine.about != "about" {
+		t.Errorf("got version %q about %q", CommandLine.version, CommandLine.about)
+	}
+}
+
+func Test_Environ(t *testing.T) {
+	lookup := Environ([]string{"A=1", "B=", "A=2", "C=x=y", "=z", "D"})
+	for _, test := range []struct {
+		name string
+		v    string
+		ok   bool
+	}{
+		//The later values win
+		{name: "A", v: "2", ok: true},
+		{name: "B", v: "", ok: true},
+		{name: "C", v: "x=y", ok: true},
+		{name: "D"},
+		{name: ""},
+	} {
+		if v, ok := lookup(test.name); v != test.v || ok != test.ok {
+			t.Errorf("%q: got %q %t, need %q %t", test.name, v, ok, test.v, test.ok)
+		}
+	}
+}
+
+// The help shows the env of the source instead of the process
+func Test_Env_Help(t *testing.T) {
+	os.Setenv("TOKEN", "process")
+	defer os.Unsetenv("TOKEN")
+
+	var w bytes.Buffer
+	s := New(nil).SetOutput(&w).SetExit(false).SetEnv(func(name string) (string, bool) {
+		if name == "TOKEN" {
+			return "source", true
+		}
+		return "", false
+	})
+	var got struct {
+		Token string `screw:"--token;env=TOKEN" usage:"token"`
+	}
+	if err := s.Register(&got); err != nil {
+		t.Fatal(err)
+	}
+	s.Usage()
+
+	if !strings.Contains(w.String(), "[env: TOKEN=source]") || strings.Contains(w.String(), "process") {
+		t.Errorf("got\n%s", w.String())
+	}
+}
+
+func Test_Env_Spec(t *testing.T) {
+	spec := MustCompile((*envConfig)(nil))
+	for _, env := range []string{"a", "b"} {
+		var got envConfig
+		if err := spec.NewBinder(nil).SetEnviron([]string{"TOKEN=" + env}).Bind(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Token != env {
+			t.Errorf("got %q, need %q", got.Token, env)
+		}
+	}
+}
+
+// The env names derived at different levels are checked against each other
+func Test_Env_Duplicate(t *testing.T) {
+	var got struct {
+		SubLong string `screw:"--sub-long" usage:"root"`
+		Sub     struct {
+			Long string `screw:"--long" usage:"sub"`
+		} `screw:"subcommand=sub" usage:"sub"`
+	}
+
+	err := New(nil).SetEnvPrefix("MYAPP").SetAutoEnv(true).Register(&got)
+	if err == nil || err.Error() != ErrDuplicateOptions.Error()+": env=MYAPP_SUB_LONG" {
+		t.Errorf("got %v", err)
+	}
+}
+
+// The env options are not positionals, an unknown subcommand is reported in AutoEnv mode too
+func Test_Env_UnknownSubcommand(t *testing.T) {
+	for _, auto := range []bool{false, true} {
+		err := New([]string{"bogus"}).SetExit(false).SetOutput(ioutil.Discard).SetAutoEnv(auto).Bind(new(envConfig))
+		if err == nil || err.Error() != "Unknown subcommand:bogus" {
+			t.Errorf("AutoEnv %t: got %v", auto, err)
+		}
+	}
+}
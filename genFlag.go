@@ -4,9 +4,15 @@ import (
 	"bytes"
 	"fmt"
 	"go/format"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 )
 
+// The import path of the screw package in the generated code
+const screwImportPath = "github.com/RainFallsSilent/screw"
+
 func genStructName(k string) string {
 	return k + "AutoGen"
 }
@@ -15,26 +21,195 @@ func genVarName(varName string) string {
 	return varName + "Var"
 }
 
+// Generate the field name from the variable name, or the option name if there is no variable
+// port -> Port, dry-run -> DryRun
+func genFieldName(arg flagOpt) string {
+	name := arg.varName
+	if len(name) == 0 {
+		name = arg.optName
+	}
+
+	var field strings.Builder
+	upper := true
+	for _, b := range []byte(name) {
+		if b == '-' || b == '_' || b == '.' {
+			upper = true
+			continue
+		}
+
+		if upper && b >= 'a' && b <= 'z' {
+			b = b - 'a' + 'A'
+		}
+		upper = false
+		field.WriteByte(b)
+	}
+	return field.String()
+}
+
+// Generate the struct tag of the option
+func genTag(arg flagOpt) string {
+	var screwTag bytes.Buffer
+
+	//Write Option Name
+	screwTag.WriteString("`screw:\"")
+	if len(arg.short) > 0 {
+		screwTag.WriteString(fmt.Sprintf("-%s;", arg.short))
+	}
+	numMinuses := "-"
+	if len(arg.optName) > 1 {
+		numMinuses = "--"
+	}
+	screwTag.WriteString(fmt.Sprintf("%s%s", numMinuses, arg.optName))
+	for _, long := range arg.long {
+		screwTag.WriteString(fmt.Sprintf(";--%s", long))
+	}
+	if len(arg.env) > 0 {
+		screwTag.WriteString(fmt.Sprintf(";env=%s", arg.env))
+	}
+	screwTag.WriteString("\" ")
+
+	//Write the separator of slices and maps
+	if len(arg.sep) > 0 {
+		screwTag.WriteString(fmt.Sprintf("sep:%s ", strconv.Quote(arg.sep)))
+	}
+
+	//Write Default
+	if len(arg.defVal) > 0 {
+		screwTag.WriteString(fmt.Sprintf("default:%s ", strconv.Quote(arg.defVal)))
+	}
+
+	//Write help information
+	if len(arg.usage) > 0 {
+		screwTag.WriteString(fmt.Sprintf("usage:%s ", strconv.Quote(arg.usage)))
+	}
+
+	//The required bool has to be true, and the default always satisfies required
+	var notes []string
+	switch {
+	case !arg.required:
+	case arg.typeName == "bool":
+		notes = append(notes, "Required: not migrated, a required bool would have to be true")
+	case !isZeroDefault(arg.defVal):
+		notes = append(notes, "Required: not migrated, the default "+strconv.Quote(arg.defVal)+" always satisfies it")
+	default:
+		screwTag.WriteString("valid:\"required\" ")
+	}
+
+	screwTag.WriteString("`")
+
+	//The function of flag.Func has to be called with the value
+	notes = append(append([]string(nil), arg.notes...), notes...)
+	if len(arg.funcName) > 0 {
+		notes = append([]string{"Func: " + arg.funcName}, notes...)
+	}
+	if len(arg.defExpr) > 0 {
+		notes = append(notes, "TODO: default "+arg.defExpr+" not migrated")
+	}
+	if len(notes) > 0 {
+		screwTag.WriteString(" // " + strings.Join(notes, "; "))
+	}
+
+	screwTag.WriteString("\n")
+	return screwTag.String()
+}
+
+// The packages of the field types, such as time of time.Duration
+var typePackages = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)\.`)
+
+// Generate the imports of screw and the packages the field types need.
+// The paths are taken from the imports of the source, the types of the flag functions are in net and time
+func (p *ParseFlag) genImports(args []flagOpt) string {
+	paths := map[string]string{"net": "net", "time": "time"}
+	for _, imp := range p.astFile.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		name := path[strings.LastIndex(path, "/")+1:]
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		paths[name] = path
+	}
+
+	var std, other []string
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		if len(arg.optName) == 0 {
+			continue
+		}
+
+		for _, m := range typePackages.FindAllStringSubmatch(arg.typeName, -1) {
+			path, ok := paths[m[1]]
+			if !ok || seen[path] {
+				continue
+			}
+			seen[path] = true
+
+			imp := strconv.Quote(path)
+			if name := path[strings.LastIndex(path, "/")+1:]; name != m[1] {
+				imp = m[1] + " " + imp
+			}
+			if strings.Contains(strings.SplitN(path, "/", 2)[0], ".") {
+				other = append(other, imp)
+			} else {
+				std = append(std, imp)
+			}
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+
+	var code strings.Builder
+	code.WriteString("import (\n")
+	for _, imp := range std {
+		code.WriteString(imp + "\n")
+	}
+	if len(std) > 0 {
+		code.WriteString("\n")
+	}
+	for _, imp := range append(other, strconv.Quote(screwImportPath)) {
+		code.WriteString(imp + "\n")
+	}
+	code.WriteString(")\n")
+	return code.String()
+}
+
+// The options of the command and its subcommands
+func commandArgs(d *commandDef, args []flagOpt) []flagOpt {
+	args = append(args, d.args...)
+	for _, sub := range d.subs {
+		args = commandArgs(sub, args)
+	}
+	return args
+}
+
+// The default leaves the value zero, such as "" or 0
+func isZeroDefault(def string) bool {
+	switch def {
+	case "", "false", "[]", "{}", `""`:
+		return true
+	}
+	f, err := strconv.ParseFloat(def, 64)
+	return err == nil && f == 0
+}
+
 // Generate the structure according to the resolved function name and parameters
 func genStructBytes(p *ParseFlag) ([]byte, error) {
 
 	var code bytes.Buffer
 	var allCode bytes.Buffer
 
-	for k, funcAndArgs := range p.funcAndArgs {
-		v := funcAndArgs
+	for _, k := range p.sortedNames() {
+		v := p.funcAndArgs[k]
 		if !v.haveParseFunc {
 			continue
 		}
 
 		if p.haveImportPath {
-			code.WriteString(`
-
-			package main
-			import (
-				"github.com/fungolang/screw"
-			)
-			`)
+			code.WriteString("\n\npackage main\n")
+			code.WriteString(p.genImports(v.args))
 		}
 
 		if !p.haveStruct {
@@ -45,41 +220,12 @@ func genStructBytes(p *ParseFlag) ([]byte, error) {
 
 		for _, arg := range v.args {
 			//The option name is important. If there is no option name, it will not be generated
-			if len(arg.optName) == 0 || len(arg.varName) == 0 {
+			if len(arg.optName) == 0 || len(arg.typeName) == 0 {
 				continue
 			}
 			//Write field name and type name
-			varName := arg.varName
-			if varName[0] >= 'a' && varName[0] <= 'z' {
-				varName = string(varName[0]-'a'+'A') + varName[1:]
-			}
-
-			code.WriteString(fmt.Sprintf("%s %s", varName, arg.typeName))
-
-			var screwTag bytes.Buffer
-
-			//Write Option Name
-			if len(arg.optName) > 0 {
-				screwTag.WriteString("`screw:\"")
-				numMinuses := "-"
-				if len(arg.optName) > 1 {
-					numMinuses = "--"
-				}
-				screwTag.WriteString(fmt.Sprintf("%s%s\" ", numMinuses, arg.optName))
-			}
-
-			//Write Default
-			if len(arg.defVal) > 0 {
-				screwTag.WriteString(fmt.Sprintf("default:\"%s\" ", arg.defVal))
-			}
-
-			//Write help information
-			if len(arg.usage) > 0 {
-				screwTag.WriteString(fmt.Sprintf("usage:\"%s\" `\n", arg.usage))
-			}
-
-			code.WriteString(screwTag.String())
-
+			code.WriteString(fmt.Sprintf("%s %s", genFieldName(arg), arg.typeName))
+			code.WriteString(genTag(arg))
 		}
 
 		code.WriteString("}")
@@ -88,7 +234,7 @@ func genStructBytes(p *ParseFlag) ([]byte, error) {
 			code.WriteString(fmt.Sprintf(`
 			func main() {
 			var %s %s
-			screw.Bind(&%s)
+			screw.MustBind(&%s)
 			}`, genVarName(varName), genStructName(k), genVarName(varName)))
 		}
 
@@ -98,6 +244,10 @@ func genStructBytes(p *ParseFlag) ([]byte, error) {
 		}
 
 		allCode.Write(fmtCode)
+		//The code of the next structure starts on a new line
+		if !bytes.HasSuffix(fmtCode, []byte("\n")) {
+			allCode.WriteByte('\n')
+		}
 
 		code.Reset()
 
@@ -105,3 +255,106 @@ func genStructBytes(p *ParseFlag) ([]byte, error) {
 
 	return allCode.Bytes(), nil
 }
+
+// Generate the struct of the command, the structs of the subcommands are generated before it
+func genCommandStruct(code *bytes.Buffer, d *commandDef, names map[*commandDef]string, done map[*commandDef]bool) {
+	done[d] = true
+	for _, sub := range d.subs {
+		if !done[sub] {
+			genCommandStruct(code, sub, names, done)
+		}
+	}
+
+	code.WriteString(fmt.Sprintf("type %s struct{", genStructName(names[d])))
+	for _, arg := range d.args {
+		if len(arg.optName) == 0 || len(arg.typeName) == 0 {
+			continue
+		}
+		//The option of the struct is only parsed before the subcommand
+		if arg.persistent && len(d.subs) > 0 {
+			arg.notes = append(append([]string(nil), arg.notes...), "PersistentFlags: not inherited by the subcommands")
+		}
+		code.WriteString(fmt.Sprintf("%s %s", genFieldName(arg), arg.typeName))
+		code.WriteString(genTag(arg))
+	}
+
+	for _, sub := range d.subs {
+		code.WriteString(fmt.Sprintf("%s %s `screw:\"subcommand=%s\"", genFieldName(flagOpt{optName: sub.name()}), genStructName(names[sub]), sub.name()))
+		if len(sub.usage) > 0 {
+			code.WriteString(fmt.Sprintf(" usage:%s", strconv.Quote(sub.usage)))
+		}
+		code.WriteString("`\n")
+	}
+	code.WriteString("}\n")
+}
+
+// Name the structs of the commands, the names are unique
+func genCommandNames(d *commandDef, names map[*commandDef]string, used map[string]bool) {
+	if _, ok := names[d]; ok {
+		return
+	}
+
+	//The name of the command is preferred, the variables are often named cmd
+	name := genFieldName(flagOpt{optName: d.name()})
+	if len(name) == 0 {
+		name = "command"
+	}
+	name = strings.ToLower(name[:1]) + name[1:]
+
+	for i := 2; used[name]; i++ {
+		name = fmt.Sprintf("%s%d", strings.TrimRight(name, "0123456789"), i)
+	}
+	used[name] = true
+	names[d] = name
+
+	for _, sub := range d.subs {
+		genCommandNames(sub, names, used)
+	}
+}
+
+// Generate the structures of the commands of cobra and urfave/cli, every root command is one tree
+func genCommandBytes(p *ParseFlag) ([]byte, error) {
+	var allCode bytes.Buffer
+	names := make(map[*commandDef]string)
+	used := make(map[string]bool)
+
+	for _, d := range p.commands {
+		if d.isSub {
+			continue
+		}
+
+		genCommandNames(d, names, used)
+
+		var code bytes.Buffer
+		if p.haveImportPath {
+			code.WriteString("\n\npackage main\n")
+			code.WriteString(p.genImports(commandArgs(d, nil)))
+		}
+
+		if !p.haveStruct {
+			continue
+		}
+
+		genCommandStruct(&code, d, names, make(map[*commandDef]bool))
+		if p.haveMain {
+			varName := genVarName(names[d])
+			code.WriteString(fmt.Sprintf(`
+			func main() {
+			var %s %s
+			screw.MustBind(&%s)
+			}`, varName, genStructName(names[d]), varName))
+		}
+
+		fmtCode, err := format.Source(code.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		allCode.Write(fmtCode)
+		//The code of the next structure starts on a new line
+		if !bytes.HasSuffix(fmtCode, []byte("\n")) {
+			allCode.WriteByte('\n')
+		}
+	}
+
+	return allCode.Bytes(), nil
+}
@@ -6,6 +6,7 @@ require (
 	github.com/go-playground/universal-translator v0.18.0
 	github.com/go-playground/validator/v10 v10.10.1
 	github.com/stretchr/testify v1.7.1 // indirect
+	golang.org/x/term v0.0.0-20210503060354-a79de5458b56
 )
 
 go 1.13
@@ -30,6 +30,13 @@ type showOption struct {
 	Usage   string
 	Env     string
 	Default string
+	Choices string
+}
+
+// showGroup is the options of the structures registered with the same prefix
+type showGroup struct {
+	Prefix  string
+	Options []showOption
 }
 
 type Help struct {
@@ -40,11 +47,22 @@ type Help struct {
 	Options          []showOption
 	Args             []showOption
 	Envs             []showOption
+	Groups           []showGroup
 	Subcommand       []showOption
 	MaxNameLen       int
 	ShowUsageDefault bool
 }
 
+func (h *Help) addGroupOption(prefix string, o showOption) {
+	for i := range h.Groups {
+		if h.Groups[i].Prefix == prefix {
+			h.Groups[i].Options = append(h.Groups[i].Options, o)
+			return
+		}
+	}
+	h.Groups = append(h.Groups, showGroup{Prefix: prefix, Options: []showOption{o}})
+}
+
 func (h *Help) output(w io.Writer) error {
 	sort.Slice(h.Flags, func(i, j int) bool {
 		return h.Flags[i].Opt < h.Flags[j].Opt
@@ -54,6 +72,17 @@ func (h *Help) output(w io.Writer) error {
 		return h.Options[i].Opt < h.Options[j].Opt
 	})
 
+	sort.Slice(h.Groups, func(i, j int) bool {
+		return h.Groups[i].Prefix < h.Groups[j].Prefix
+	})
+
+	for _, g := range h.Groups {
+		options := g.Options
+		sort.Slice(options, func(i, j int) bool {
+			return options[i].Opt < options[j].Opt
+		})
+	}
+
 	sort.Slice(h.Subcommand, func(i, j int) bool {
 		return h.Subcommand[i].Opt < h.Subcommand[j].Opt
 	})
@@ -65,10 +94,10 @@ var usageDefaultTmpl = `{{- $ShowUsageDefault := .ShowUsageDefault}}{{- if gt (l
 {{- .About}}
 
 {{end}}
-{{- if or (gt (len .Flags) 0) (gt (len .Options) 0) (gt (len .Args) 0) (gt (len .Subcommand) 0)}}Usage:
+{{- if or (gt (len .Flags) 0) (gt (len .Options) 0) (gt (len .Groups) 0) (gt (len .Args) 0) (gt (len .Subcommand) 0)}}Usage:
     {{if gt (len .ProcessName) 0}}{{.ProcessName}} {{end}}
 {{- if gt (len .Flags) 0}}[Flags] {{end}}
-{{- if gt (len .Options) 0}}[Options] {{end}}
+{{- if or (gt (len .Options) 0) (gt (len .Groups) 0)}}[Options] {{end}}
 {{- range $_, $flag := .Args}}{{$flag.Opt}} {{end}}
 {{- if gt (len .Subcommand) 0}}<Subcommand> {{end}}
 {{- end}}
@@ -80,6 +109,7 @@ Flags:
 {{- $length := len .Flags}}
 {{- $length = sub $length}}
 {{range $index, $flag:= .Flags}}    {{addSpace $maxNameLen (len $flag.Opt)|printf "%s%s" $flag.Opt}}    {{$flag.Usage}}
+{{- if gt (len $flag.Choices) 0 }} [choices: {{$flag.Choices}}] {{- end}}
 {{- if gt (len $flag.Env) 0 }} [env: {{$flag.Env}}] {{- end}}
 {{- if and (gt (len $flag.Default) 0) $ShowUsageDefault}} [default: {{$flag.Default}}] {{- end}}
 {{- if ne $index $length}}
@@ -95,6 +125,23 @@ Options:
 {{- $length := len .Options}}
 {{- $length = sub $length}}
 {{range $index, $flag:= .Options}}    {{addSpace $maxNameLen (len $flag.Opt)|printf "%s%s" $flag.Opt}}    {{$flag.Usage}} 
+{{- if gt (len $flag.Choices) 0 }} [choices: {{$flag.Choices}}]{{- end}}
+{{- if gt (len $flag.Env) 0 }} [env: {{$flag.Env}}]{{- end}}
+{{- if and (gt (len $flag.Default) 0 ) $ShowUsageDefault}} [default: {{$flag.Default}}]{{- end}}
+{{- if ne $index $length}}
+{{end}}
+
+{{- end}}
+{{- end}}
+
+
+{{- range $_, $group := .Groups}}
+
+Options (--{{$group.Prefix}}*):
+{{- $length := len $group.Options}}
+{{- $length = sub $length}}
+{{range $index, $flag:= $group.Options}}    {{addSpace $maxNameLen (len $flag.Opt)|printf "%s%s" $flag.Opt}}    {{$flag.Usage}}
+{{- if gt (len $flag.Choices) 0 }} [choices: {{$flag.Choices}}]{{- end}}
 {{- if gt (len $flag.Env) 0 }} [env: {{$flag.Env}}]{{- end}}
 {{- if and (gt (len $flag.Default) 0 ) $ShowUsageDefault}} [default: {{$flag.Default}}]{{- end}}
 {{- if ne $index $length}}
@@ -109,6 +156,7 @@ Args:
 {{- $length := len .Args}}
 {{- $length = sub $length}}
 {{range $index, $flag:= .Args}}    {{addSpace $maxNameLen (len $flag.Opt)|printf "%s%s" $flag.Opt}}    {{$flag.Usage}}
+{{- if gt (len $flag.Choices) 0 }} [choices: {{$flag.Choices}}]{{- end}}
 {{- if gt (len $flag.Env) 0 }} [env: {{$flag.Env}}]{{- end}}
 {{- if ne $index $length}}
 {{end}}
@@ -0,0 +1,103 @@
+package screw
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type mapConfig struct {
+	Env    map[string]string `screw:"-e;--env;greedy" usage:"set environment variables"`
+	Props  map[string]int    `screw:"-D;once" usage:"set a property"`
+	Labels map[string]string `screw:"--label" default:"team=core" usage:"labels"`
+	Limits map[int]float64   `screw:"--limit" sep:";" usage:"limits"`
+}
+
+func Test_Map(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		need mapConfig
+	}{
+		{
+			need: mapConfig{Labels: map[string]string{"team": "core"}},
+		},
+		{
+			args: []string{"-e", "env=prod", "--env", "team=core", "-Dretries=3", "-D", "timeout=10"},
+			need: mapConfig{
+				Env:    map[string]string{"env": "prod", "team": "core"},
+				Props:  map[string]int{"retries": 3, "timeout": 10},
+				Labels: map[string]string{"team": "core"},
+			},
+		},
+		{
+			//Greedy takes the pairs up to the next option
+			args: []string{"-e", "a=1", "b=2=3", "-D=x=1"},
+			need: mapConfig{
+				Env:    map[string]string{"a": "1", "b": "2=3"},
+				Props:  map[string]int{"x": 1},
+				Labels: map[string]string{"team": "core"},
+			},
+		},
+		{
+			//The command line replaces the default
+			args: []string{"--label", "a=b", "--label", "c=d", "--label", "e="},
+			need: mapConfig{Labels: map[string]string{"a": "b", "c": "d", "e": ""}},
+		},
+		{
+			//Without sep, the pairs are split by comma, a part without = belongs to the previous value
+			args: []string{"-e", "K=a,b", "-DFOO=1,BAR=2", "--label", "x=y,z"},
+			need: mapConfig{Env: map[string]string{"K": "a,b"}, Props: map[string]int{"FOO": 1, "BAR": 2}, Labels: map[string]string{"x": "y,z"}},
+		},
+		{
+			args: []string{"--limit", "1=0.5;2=1.5", "--label", `{"x":"y"}`},
+			need: mapConfig{Labels: map[string]string{"x": "y"}, Limits: map[int]float64{1: 0.5, 2: 1.5}},
+		},
+	} {
+		var got mapConfig
+		spec := MustCompile((*mapConfig)(nil))
+		if err := spec.Bind(test.args, &got); err != nil {
+			t.Fatalf("%q: %v", test.args, err)
+		}
+
+		if !reflect.DeepEqual(got, test.need) {
+			t.Errorf("%q: got %+v, need %+v", test.args, got, test.need)
+		}
+	}
+}
+
+func Test_Map_Default(t *testing.T) {
+	type config struct {
+		Props  map[string]int    `screw:"-D" default:"a=1,b=2" usage:"props"`
+		Labels map[string]string `screw:"--label" default:"k=a,b=c" usage:"labels"`
+	}
+
+	//The default of a map is split strictly by comma like the env values
+	var got config
+	spec := MustCompile((*config)(nil))
+	if err := spec.Bind(nil, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	need := config{Props: map[string]int{"a": 1, "b": 2}, Labels: map[string]string{"k": "a", "b": "c"}}
+	if !reflect.DeepEqual(got, need) {
+		t.Errorf("got %+v, need %+v", got, need)
+	}
+}
+
+func Test_Map_Error(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		msg  string
+	}{
+		{args: []string{"-Dretries=3", "-Dretries=4"}, msg: "The key 'retries' was provided more than once"},
+		{args: []string{"-Dretries=x"}, msg: "invalid syntax"},
+		{args: []string{"--env", "novalue"}, msg: "want key=value"},
+		{args: []string{"--limit", "x=1"}, msg: "invalid syntax"},
+	} {
+		spec := MustCompile((*mapConfig)(nil))
+		err := spec.Bind(test.args, new(mapConfig))
+		if err == nil || !strings.Contains(err.Error(), test.msg) {
+			t.Errorf("%q: got %v, need %q", test.args, err, test.msg)
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package screw
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Marshal renders the structure that x points to back to the arguments that bind it.
+// The long names are preferred, the values equal to the defaults are skipped, and a subcommand
+// is emitted if any of its options is set. The secret values are redacted, use MarshalSecrets to re-exec,
+// the callbacks and the env only options are not rendered
+func Marshal(x interface{}) ([]string, error) {
+	return marshal(x, false)
+}
+
+// MarshalSecrets is similar to Marshal, the secret values are included, such as for re-exec
+func MarshalSecrets(x interface{}) ([]string, error) {
+	return marshal(x, true)
+}
+
+func marshal(x interface{}, secrets bool) ([]string, error) {
+	s, err := Compile(x)
+	if err != nil {
+		return nil, err
+	}
+
+	b := s.NewBinder(nil)
+	if b.roots, err = b.checkRoots([]interface{}{x}); err != nil {
+		return nil, err
+	}
+	return b.marshal(true, secrets)
+}
+
+// Render the bound structures back to the arguments, the subcommands are the ones set by the command line.
+// The secret values are redacted
+func (b *Binder) Marshal() ([]string, error) {
+	if b.roots == nil {
+		return nil, errors.New("error: the structures are not bound")
+	}
+	return b.marshal(false, false)
+}
+
+// See Binder.Marshal, the secret values are included
+func (b *Binder) MarshalSecrets() ([]string, error) {
+	if b.roots == nil {
+		return nil, errors.New("error: the structures are not bound")
+	}
+	return b.marshal(false, true)
+}
+
+// See Binder.Marshal
+func (c *Screw) Marshal() ([]string, error) {
+	if c.binder == nil {
+		return nil, errors.New("error: the structures are not bound")
+	}
+	return c.binder.Marshal()
+}
+
+// See Binder.MarshalSecrets
+func (c *Screw) MarshalSecrets() ([]string, error) {
+	if c.binder == nil {
+		return nil, errors.New("error: the structures are not bound")
+	}
+	return c.binder.MarshalSecrets()
+}
+
+// Join the arguments into one command line, the arguments are quoted for the shell when needed.
+// It is the reverse of Split
+func Join(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func shellQuote(s string) string {
+	if len(s) == 0 {
+		return "''"
+	}
+
+	safe := true
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,+@%", r)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+func (b *Binder) marshal(infer bool, secrets bool) ([]string, error) {
+	var args []string
+	cmd := b.cmd
+	for {
+		a, err := b.marshalCommand(cmd, secrets)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, a...)
+
+		var set []string
+		for name, sub := range cmd.subcommand {
+			if infer && b.isCommandSet(sub.command) || !infer && b.IsSetSubcommand(name) {
+				set = append(set, name)
+			}
+		}
+		if len(set) == 0 {
+			return args, nil
+		}
+
+		//Only one subcommand of a level can be given, it takes the rest of the arguments
+		if len(set) > 1 {
+			sort.Strings(set)
+			return nil, fmt.Errorf("error: more than one subcommand is set: %s", strings.Join(set, ", "))
+		}
+
+		args = append(args, set[0])
+		cmd = cmd.subcommand[set[0]].command
+	}
+}
+
+// Whether an option of the command or its subcommands differs from the default
+func (b *Binder) isCommandSet(c *command) bool {
+	for _, o := range c.allOptions() {
+		if b.isMarshaled(o) {
+			return true
+		}
+	}
+	return false
+}
+
+// The options that are rendered, the env only options and the callbacks can not be
+func (b *Binder) isMarshaled(o *Option) bool {
+	if len(o.fnName) > 0 || len(o.showShort) == 0 && len(o.showLong) == 0 && len(o.argsName) == 0 {
+		return false
+	}
+
+	v, ok := b.lookupValue(o.field)
+	if !ok {
+		return false
+	}
+
+	def := reflect.New(o.typ).Elem()
+	if len(o.showDefValue) > 0 {
+		if err := setDefaultValue(o.showDefValue, o.sep, o.layout, def); err != nil {
+			return true
+		}
+	} else if b.isNonNilPointer(o.field) {
+		//The pointer is allocated only when the option is set
+		return true
+	}
+
+	return !reflect.DeepEqual(v.Interface(), def.Interface())
+}
+
+func (b *Binder) isNonNilPointer(p fieldPath) bool {
+	v, ok := b.lookupValue(p.parent())
+	if !ok {
+		return false
+	}
+	return v.Field(p.index[len(p.index)-1]).Kind() == reflect.Ptr
+}
+
+func (b *Binder) marshalCommand(c *command, secrets bool) ([]string, error) {
+	var args, positionals []string
+	for _, o := range c.options() {
+		if !b.isMarshaled(o) {
+			continue
+		}
+
+		v, _ := b.lookupValue(o.field)
+		values, err := o.marshalValues(v)
+		if err != nil {
+			return nil, err
+		}
+		//Nothing is rendered for an empty slice or map, it would bind back to the default
+		if len(values) == 0 && len(o.showDefValue) > 0 {
+			return nil, fmt.Errorf("error: the empty value of %s can not be rendered, its default is %s", o.showName(), o.showDefValue)
+		}
+		if !secrets {
+			for i := range values {
+				values[i] = o.redact(values[i])
+			}
+		}
+
+		if len(o.showShort) == 0 && len(o.showLong) == 0 {
+			for _, val := range values {
+				if len(val) == 0 || val[0] == '-' || c.subcommand[val] != nil {
+					return nil, fmt.Errorf("error: the value (%s) of <%s> can not be an argument", val, o.argsName)
+				}
+			}
+			positionals = append(positionals, values...)
+			continue
+		}
+
+		for _, val := range values {
+			a, err := o.marshalArg(val)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, a...)
+		}
+	}
+	return append(args, positionals...), nil
+}
+
+// The arguments of one value, such as --name=value, -nvalue or --flag
+func (o *Option) marshalArg(val string) ([]string, error) {
+	//Such as -vvv of []bool
+	isBool := o.kind() == reflect.Bool || (o.kind() == reflect.Slice || o.kind() == reflect.Array) && o.typ.Elem().Kind() == reflect.Bool
+	if len(o.showLong) > 0 {
+		name := "--" + o.showLong[0]
+		switch {
+		case isBool && val == "true":
+			return []string{name}, nil
+		case len(val) == 0:
+			return []string{name, val}, nil
+		}
+		return []string{name + "=" + val}, nil
+	}
+
+	name := "-" + o.showShort[0]
+	switch {
+	case isBool && val == "true":
+		return []string{name}, nil
+	case isBool:
+		//-cfalse is the cluster -c -f -a -l -s -e
+		return []string{name + "=" + val}, nil
+	case len(val) == 0:
+		//The empty argument after a short option is skipped, and -s= sets =
+		return nil, fmt.Errorf("error: the empty value of %s can not be an argument", name)
+	case val[0] == '=':
+		return []string{name, val}, nil
+	}
+	return []string{name + val}, nil
+}
+
+// The values of the option, one per occurrence
+func (o *Option) marshalValues(v reflect.Value) ([]string, error) {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		//Such as net.IP
+		if isSelfParsing(v.Type()) {
+			break
+		}
+
+		values := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			val, err := o.marshalElem(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, val)
+		}
+
+		if o.once && len(values) > 1 {
+			if len(o.sep) == 0 {
+				return nil, fmt.Errorf("error: %s takes one value, it can not hold %d values", o.showName(), len(values))
+			}
+			return []string{strings.Join(values, o.sep)}, nil
+		}
+		return values, nil
+	case reflect.Map:
+		return o.marshalMap(v)
+	}
+
+	val, err := o.formatValue(v)
+	return []string{val}, err
+}
+
+// An element of a slice or an array, the elements holding several values are joined by the separator or in JSON
+func (o *Option) marshalElem(v reflect.Value) (string, error) {
+	if isMultiValue(v.Type()) {
+		if v.Kind() == reflect.Struct {
+			data, err := json.Marshal(v.Interface())
+			return string(data), err
+		}
+
+		inner, err := o.marshalValues(v)
+		if err != nil {
+			return "", err
+		}
+
+		sep := multiValueSep(o.sep)
+		for _, val := range inner {
+			if strings.Contains(val, sep) {
+				return "", fmt.Errorf("error: the value (%s) of %s contains the separator %q", o.redact(val), o.showName(), sep)
+			}
+		}
+		return strings.Join(inner, sep), nil
+	}
+
+	val, err := o.formatValue(v)
+	if err != nil {
+		return "", err
+	}
+
+	//The separator splits the value again
+	if len(o.sep) > 0 && strings.Contains(val, o.sep) {
+		return "", fmt.Errorf("error: the value (%s) of %s contains the separator %q", o.redact(val), o.showName(), o.sep)
+	}
+	return val, nil
+}
+
+// The pairs of the map, one per occurrence, or a JSON object if a key or a value holds the separator or =.
+// Without sep, the pairs are split by comma, so a comma is also marshaled as JSON
+func (o *Option) marshalMap(v reflect.Value) ([]string, error) {
+	sep := o.sep
+	keys := v.MapKeys()
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		key, err := o.formatValue(k)
+		if err != nil {
+			return nil, err
+		}
+		val, err := o.formatValue(v.MapIndex(k))
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.Contains(key, "=") || strings.Contains(key+val, multiValueSep(sep)) || strings.HasPrefix(strings.TrimSpace(key), "{") {
+			data, err := json.Marshal(v.Interface())
+			return []string{string(data)}, err
+		}
+		pairs = append(pairs, key+"="+val)
+	}
+	sort.Strings(pairs)
+
+	if o.once && len(sep) > 0 {
+		return []string{strings.Join(pairs, sep)}, nil
+	}
+	return pairs, nil
+}
+
+// Format one value the way it is parsed
+func (o *Option) formatValue(v reflect.Value) (string, error) {
+	switch x := v.Interface().(type) {
+	case time.Time:
+		layout := o.layout
+		if len(layout) == 0 {
+			layout = time.RFC3339Nano
+		}
+		return x.Format(layout), nil
+	case time.Duration:
+		return x.String(), nil
+	case Secret:
+		return x.Value(), nil
+	case encoding.TextMarshaler:
+		text, err := x.MarshalText()
+		return string(text), err
+	case fmt.Stringer:
+		if isSelfParsing(v.Type()) {
+			return x.String(), nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), nil
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return o.formatValue(v.Elem())
+		}
+		return "", nil
+	}
+	return "", fmt.Errorf("error: can not render the value of %s (%s)", o.showName(), v.Type())
+}
@@ -0,0 +1,218 @@
+package screw
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type marshalServe struct {
+	Port int      `screw:"-p;--port" default:"8080" usage:"port"`
+	Dirs []string `screw:"args=dirs" usage:"dirs"`
+}
+
+type marshalConfig struct {
+	Debug   bool              `screw:"-d;--debug" usage:"debug"`
+	Quiet   bool              `screw:"-q" usage:"quiet"`
+	Color   bool              `screw:"-c" default:"true" usage:"color"`
+	Verbose []bool            `screw:"-v" usage:"verbose"`
+	Name    string            `screw:"-n;--name" default:"app" usage:"name"`
+	Tags    []string          `screw:"--tag;once" sep:"," usage:"tags"`
+	Labels  map[string]string `screw:"-L" usage:"labels"`
+	Props   map[string]int    `screw:"--prop;once" sep:"," usage:"props"`
+	Level   int               `screw:"--level" default:"3" usage:"level"`
+	Wait    time.Duration     `screw:"--wait" default:"1s" usage:"wait"`
+	Token   string            `screw:"--token;secret" usage:"token"`
+	Files   []string          `screw:"args=files" usage:"files"`
+	Serve   marshalServe      `screw:"subcommand=serve" usage:"serve"`
+}
+
+type marshalSubs struct {
+	A marshalServe `screw:"subcommand=a"`
+	B marshalServe `screw:"subcommand=b"`
+}
+
+func Test_Marshal_RoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		need []string
+	}{
+		{
+			//The defaults are skipped
+			args: nil,
+			need: nil,
+		},
+		{
+			args: []string{"--name", "app", "--level=3", "--wait", "1000ms"},
+			need: nil,
+		},
+		{
+			args: []string{"-dqvv", "--name", "x", "--level", "0"},
+			need: []string{"--debug", "-q", "-v", "-v", "--name=x", "--level=0"},
+		},
+		{
+			args: []string{"--name", "", "--wait", "1m30s"},
+			need: []string{"--name", "", "--wait=1m30s"},
+		},
+		{
+			args: []string{"-L", "b=2", "-L", "a=1,c=x y", "--prop", "x=1,y=2", "--tag", "a,b"},
+			need: []string{"--tag=a,b", "-La=1", "-Lb=2", "-Lc=x y", "--prop=x=1,y=2"},
+		},
+		{
+			//Without sep, a comma in the map is marshaled as JSON
+			args: []string{"-L", "k=a,b"},
+			need: []string{`-L{"k":"a,b"}`},
+		},
+		{
+			//The positional arguments come after the options of their command
+			args: []string{"f1", "--token", "s3cret", "f2", "serve", "d1", "-p", "81"},
+			need: []string{"--token=s3cret", "f1", "f2", "serve", "--port=81", "d1"},
+		},
+		{
+			args: []string{"-d", "serve"},
+			need: []string{"--debug", "serve"},
+		},
+		{
+			//The short bool differs from its default
+			args: []string{"-c=false", "-q"},
+			need: []string{"-q", "-c=false"},
+		},
+	} {
+		var first marshalConfig
+		s := New(test.args).SetExit(false)
+		if err := s.Bind(&first); err != nil {
+			t.Fatalf("%q: %v", test.args, err)
+		}
+
+		args, err := s.MarshalSecrets()
+		if err != nil {
+			t.Fatalf("%q: %v", test.args, err)
+		}
+		if !reflect.DeepEqual(args, test.need) {
+			t.Errorf("%q: got %q, need %q", test.args, args, test.need)
+		}
+
+		var second marshalConfig
+		if err := New(args).SetExit(false).Bind(&second); err != nil {
+			t.Fatalf("%q: %v", args, err)
+		}
+		if !reflect.DeepEqual(first, second) {
+			t.Errorf("%q: got %+v, need %+v", args, second, first)
+		}
+
+		//The words survive the shell
+		words, err := Split(Join(args))
+		if err != nil || len(args) > 0 && !reflect.DeepEqual(words, args) {
+			t.Errorf("%q: got %q %v", args, words, err)
+		}
+	}
+
+	//The values that would bind back to the defaults are errors
+	type defaults struct {
+		Tags  []string `screw:"--tag" default:"[\"a\",\"b\"]" usage:"tags"`
+		Short string   `screw:"-s" default:"x" usage:"short"`
+	}
+	for _, test := range []struct {
+		x   defaults
+		msg string
+	}{
+		{x: defaults{Tags: []string{}, Short: "x"}, msg: "the empty value of --tag"},
+		{x: defaults{Tags: []string{"a", "b"}, Short: ""}, msg: "the empty value of -s"},
+	} {
+		if _, err := Marshal(&test.x); err == nil || !strings.Contains(err.Error(), test.msg) {
+			t.Errorf("%+v: got %v, need %q", test.x, err, test.msg)
+		}
+	}
+}
+
+// The package level Marshal infers the subcommands from the values
+func Test_Marshal(t *testing.T) {
+	for _, test := range []struct {
+		x    marshalConfig
+		need []string
+	}{
+		{
+			x:    marshalConfig{Color: true, Name: "app", Level: 3, Wait: time.Second, Serve: marshalServe{Port: 8080}},
+			need: nil,
+		},
+		{
+			//The subcommand differs from its defaults
+			x:    marshalConfig{Color: true, Name: "app", Level: 3, Wait: time.Second},
+			need: []string{"serve", "--port=0"},
+		},
+		{
+			//The zero values differ from the defaults
+			x:    marshalConfig{Serve: marshalServe{Port: 81, Dirs: []string{"d"}}},
+			need: []string{"-c=false", "--name", "", "--level=0", "--wait=0s", "serve", "--port=81", "d"},
+		},
+	} {
+		args, err := Marshal(&test.x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(args, test.need) {
+			t.Errorf("got %q, need %q", args, test.need)
+		}
+	}
+}
+
+func Test_Marshal_Secret(t *testing.T) {
+	x := marshalConfig{Color: true, Name: "app", Level: 3, Wait: time.Second, Token: "s3cret", Serve: marshalServe{Port: 8080}}
+	args, err := Marshal(&x)
+	if err != nil || strings.Join(args, " ") != "--token=******" {
+		t.Errorf("got %q %v", args, err)
+	}
+
+	args, err = MarshalSecrets(&x)
+	if err != nil || strings.Join(args, " ") != "--token=s3cret" {
+		t.Errorf("got %q %v", args, err)
+	}
+
+	type config struct {
+		Key  Secret   `screw:"--key"`
+		Keys []string `screw:"--keys;secret" sep:","`
+	}
+	args, err = Marshal(&config{Key: "k", Keys: []string{"a"}})
+	if err != nil || strings.Join(args, " ") != "--key=****** --keys=******" {
+		t.Errorf("got %q %v", args, err)
+	}
+
+	_, err = Marshal(&config{Keys: []string{"a,b"}})
+	if err == nil || strings.Contains(err.Error(), "a,b") {
+		t.Errorf("got %v", err)
+	}
+}
+
+func Test_Marshal_Error(t *testing.T) {
+	for _, test := range []struct {
+		x   interface{}
+		msg string
+	}{
+		{
+			x:   &marshalConfig{Files: []string{"-x"}},
+			msg: "the value (-x) of <files> can not be an argument",
+		},
+		{
+			x:   &marshalConfig{Files: []string{"serve"}},
+			msg: "the value (serve) of <files> can not be an argument",
+		},
+		{
+			x:   &marshalConfig{Tags: []string{"a,b", "c"}},
+			msg: "contains the separator",
+		},
+		{
+			x:   &marshalSubs{A: marshalServe{Port: 1}, B: marshalServe{Port: 2}},
+			msg: "more than one subcommand is set: a, b",
+		},
+	} {
+		_, err := Marshal(test.x)
+		if err == nil || !strings.Contains(err.Error(), test.msg) {
+			t.Errorf("got %v, need %q", err, test.msg)
+		}
+	}
+
+	if _, err := New(nil).Marshal(); err == nil {
+		t.Error("need an error before Bind")
+	}
+}
@@ -0,0 +1,1041 @@
+package screw
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migrate rewrites the flag code of a package to screw.
+// The options of the default flag set become the fields of one struct, the reads of the flag variables
+// become the reads of the fields, the flag declarations are deleted and flag.Parse becomes screw.MustBind
+type Migrate struct {
+	dir      string
+	typeName string
+	varName  string
+}
+
+// MigrateFile is a rewritten file, Src is the original code and Dst is the formatted new code
+type MigrateFile struct {
+	Name string
+	Src  []byte
+	Dst  []byte
+}
+
+// Create a migration of the package in dir
+func NewMigrate(dir string) *Migrate {
+	return &Migrate{dir: dir, typeName: "config", varName: "cfg"}
+}
+
+// Set the name of the generated struct, the default is config
+func (m *Migrate) SetTypeName(name string) *Migrate {
+	m.typeName = name
+	return m
+}
+
+// Set the name of the generated variable, the default is cfg
+func (m *Migrate) SetVarName(name string) *Migrate {
+	m.varName = name
+	return m
+}
+
+// The functions of the default flag set that can not be migrated when they are left in the code
+var flagSetFuncs = map[string]bool{
+	"Arg":           true,
+	"BoolFunc":      true,
+	"CommandLine":   true,
+	"Func":          true,
+	"Lookup":        true,
+	"NFlag":         true,
+	"Parsed":        true,
+	"PrintDefaults": true,
+	"Set":           true,
+	"Usage":         true,
+	"Visit":         true,
+	"VisitAll":      true,
+}
+
+// The import paths of the packages that the types of the options use
+var migrateImportPath = map[string]string{
+	"net":   "net",
+	"screw": screwImportPath,
+	"time":  "time",
+}
+
+// The state of one migration
+type migration struct {
+	*Migrate
+	fset    *token.FileSet
+	pkg     *types.Package
+	info    *types.Info
+	files   []*migrateSrc
+	opts    []*migrateOpt
+	vars    map[types.Object]*migrateOpt
+	decls   map[types.Object]*migrateDecl
+	deletes map[ast.Node]*migrateDelete
+	handled map[ast.Node]bool
+	order   []*migrateDelete
+	parse   *migrateSrc
+	args    bool
+	inits   []string
+	errs    []string
+}
+
+type migrateSrc struct {
+	fset    *token.FileSet
+	name    string
+	src     []byte
+	file    *ast.File
+	parents map[ast.Node]ast.Node
+	edits   []migrateEdit
+}
+
+// Replace the bytes from pos to end with text
+type migrateEdit struct {
+	pos, end int
+	text     string
+}
+
+type migrateOpt struct {
+	flagOpt
+	field string
+	//The variable holds the pointer returned by the flag function, such as port := flag.Int(...)
+	ptr bool
+}
+
+// The node that declares a flag variable
+type migrateDecl struct {
+	src      *migrateSrc
+	node     ast.Node
+	pkgLevel bool
+	value    ast.Expr
+}
+
+// The declaration to delete, the defaults that are not literals are assigned in its place
+type migrateDelete struct {
+	src      *migrateSrc
+	node     ast.Node
+	pkgLevel bool
+	names    []*ast.Ident
+	assigns  []migrateAssign
+}
+
+type migrateAssign struct {
+	field string
+	value ast.Expr
+}
+
+// Run the migration and return the changed files, the files are not written
+func (m *Migrate) Run() ([]MigrateFile, error) {
+	mg := &migration{
+		Migrate: m,
+		fset:    token.NewFileSet(),
+		vars:    make(map[types.Object]*migrateOpt),
+		decls:   make(map[types.Object]*migrateDecl),
+		deletes: make(map[ast.Node]*migrateDelete),
+		handled: make(map[ast.Node]bool),
+	}
+
+	if err := mg.load(); err != nil {
+		return nil, err
+	}
+
+	mg.findOptions()
+	mg.checkNames()
+	mg.rewriteUses()
+	mg.rewriteDecls()
+	mg.checkLeftovers()
+
+	switch {
+	case len(mg.errs) > 0:
+	case mg.parse == nil && len(mg.opts) == 0:
+		mg.errorf(token.NoPos, "no flag code found in %s", m.dir)
+	case mg.parse == nil:
+		mg.errorf(token.NoPos, "flag.Parse is not called in %s", m.dir)
+	}
+	if len(mg.errs) > 0 {
+		return nil, errors.New(strings.Join(mg.errs, "\n"))
+	}
+
+	mg.insertStruct()
+	return mg.output()
+}
+
+func (mg *migration) errorf(pos token.Pos, format string, a ...interface{}) {
+	m := fmt.Sprintf(format, a...)
+	if pos.IsValid() {
+		m = fmt.Sprintf("%s: %s", mg.fset.Position(pos), m)
+	}
+	mg.errs = append(mg.errs, m)
+}
+
+// The package name of the import path, the major version suffix is skipped
+func importName(path string) string {
+	elems := strings.Split(path, "/")
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = elems[len(elems)-2]
+	}
+	if i := strings.Index(name, "."); i > 0 {
+		name = name[:i]
+	}
+	return strings.TrimPrefix(name, "go-")
+}
+
+// Only the identifiers are resolved, the imported packages are empty
+type migrateImporter struct{}
+
+func (migrateImporter) Import(path string) (*types.Package, error) {
+	pkg := types.NewPackage(path, importName(path))
+	pkg.MarkComplete()
+	return pkg, nil
+}
+
+// Parse the go files of the directory and resolve the identifiers
+func (mg *migration) load() error {
+	infos, err := ioutil.ReadDir(mg.dir)
+	if err != nil {
+		return err
+	}
+
+	var files []*ast.File
+	for _, fi := range infos {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		name = filepath.Join(mg.dir, name)
+		src, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		f, err := parser.ParseFile(mg.fset, name, src, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+
+		if len(files) > 0 && f.Name.Name != files[0].Name.Name {
+			return fmt.Errorf("found packages %s and %s in %s", files[0].Name.Name, f.Name.Name, mg.dir)
+		}
+
+		files = append(files, f)
+		mg.files = append(mg.files, &migrateSrc{fset: mg.fset, name: name, src: src, file: f, parents: parents(f)})
+	}
+
+	if len(files) == 0 {
+		return fmt.Errorf("no go files in %s", mg.dir)
+	}
+
+	mg.info = &types.Info{
+		Defs: make(map[*ast.Ident]types.Object),
+		Uses: make(map[*ast.Ident]types.Object),
+	}
+	//The errors are ignored, the flag functions are unknown because the imported packages are empty
+	conf := types.Config{Importer: migrateImporter{}, Error: func(error) {}}
+	mg.pkg, _ = conf.Check(files[0].Name.Name, mg.fset, files, mg.info)
+
+	for _, src := range mg.files {
+		mg.findDecls(src)
+	}
+	return nil
+}
+
+// Map every node to its parent
+func parents(f *ast.File) map[ast.Node]ast.Node {
+	m := make(map[ast.Node]ast.Node)
+	var stack []ast.Node
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		if len(stack) > 0 {
+			m[n] = stack[len(stack)-1]
+		}
+		stack = append(stack, n)
+		return true
+	})
+	return m
+}
+
+// Record the declarations of the variables, only var declarations and := are known
+func (mg *migration) findDecls(src *migrateSrc) {
+	ast.Inspect(src.file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ValueSpec:
+			_, pkgLevel := src.parents[src.parents[n]].(*ast.File)
+			for i, name := range n.Names {
+				d := &migrateDecl{src: src, node: n, pkgLevel: pkgLevel}
+				if len(n.Values) == len(n.Names) {
+					d.value = n.Values[i]
+				}
+				if obj := mg.info.Defs[name]; obj != nil {
+					mg.decls[obj] = d
+				}
+			}
+		case *ast.AssignStmt:
+			if n.Tok != token.DEFINE {
+				return true
+			}
+			for i, l := range n.Lhs {
+				ident, ok := l.(*ast.Ident)
+				if !ok {
+					continue
+				}
+				d := &migrateDecl{src: src, node: n}
+				if len(n.Rhs) == len(n.Lhs) {
+					d.value = n.Rhs[i]
+				}
+				if obj := mg.info.Defs[ident]; obj != nil {
+					mg.decls[obj] = d
+				}
+			}
+		}
+		return true
+	})
+}
+
+// The function name of a call of the flag package, such as flag.Int
+func (mg *migration) flagFunc(e ast.Expr) (sel *ast.SelectorExpr, isPflag bool, ok bool) {
+	sel, ok = e.(*ast.SelectorExpr)
+	if !ok {
+		return nil, false, false
+	}
+
+	x, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return nil, false, false
+	}
+
+	pkg, ok := mg.info.Uses[x].(*types.PkgName)
+	if !ok {
+		return nil, false, false
+	}
+
+	isPflag, ok = flagImportPath[pkg.Imported().Path()]
+	return sel, isPflag, ok
+}
+
+// The layout of the arguments if the call defines an option of the default flag set
+func (mg *migration) defineFunc(e ast.Expr) (*ast.CallExpr, argsNumAndType, bool) {
+	call, ok := e.(*ast.CallExpr)
+	if !ok {
+		return nil, argsNumAndType{}, false
+	}
+
+	sel, isPflag, ok := mg.flagFunc(call.Fun)
+	if !ok || sel.Sel.Name == "Func" || sel.Sel.Name == "BoolFunc" {
+		return nil, argsNumAndType{}, false
+	}
+
+	argsNumType, ok := funcName[sel.Sel.Name]
+	if isPflag {
+		argsNumType, ok = pflagFuncName[sel.Sel.Name]
+	}
+
+	if !ok || argsNumType.size != len(call.Args) {
+		return nil, argsNumAndType{}, false
+	}
+	return call, argsNumType, true
+}
+
+// Find the statements that define the options, and the calls of flag.Parse, flag.Args and flag.NArg
+func (mg *migration) findOptions() {
+	for _, src := range mg.files {
+		src := src
+		ast.Inspect(src.file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.ExprStmt:
+				mg.define(src, n, nil, []ast.Expr{n.X})
+			case *ast.AssignStmt:
+				mg.define(src, n, n.Lhs, n.Rhs)
+			case *ast.ValueSpec:
+				lhs := make([]ast.Expr, len(n.Names))
+				for i, name := range n.Names {
+					lhs[i] = name
+				}
+				mg.define(src, n, lhs, n.Values)
+			case *ast.CallExpr:
+				mg.findCall(src, n)
+			}
+			return true
+		})
+	}
+}
+
+func (mg *migration) findCall(src *migrateSrc, call *ast.CallExpr) {
+	sel, _, ok := mg.flagFunc(call.Fun)
+	if !ok {
+		return
+	}
+
+	switch {
+	case sel.Sel.Name == "Parse" && len(call.Args) == 0:
+		if mg.parse == nil {
+			mg.parse = src
+		}
+		src.edit(call, fmt.Sprintf("screw.MustBind(&%s)", mg.varName))
+	case sel.Sel.Name == "Args" && len(call.Args) == 0:
+		mg.args = true
+		src.edit(call, mg.varName+".Args")
+	case sel.Sel.Name == "NArg" && len(call.Args) == 0:
+		mg.args = true
+		src.edit(call, fmt.Sprintf("len(%s.Args)", mg.varName))
+	default:
+		return
+	}
+	mg.handled[sel] = true
+}
+
+// Record the options of a statement, every value of the statement has to define an option
+func (mg *migration) define(src *migrateSrc, stmt ast.Node, lhs []ast.Expr, rhs []ast.Expr) {
+	found := 0
+	for _, e := range rhs {
+		if _, _, ok := mg.defineFunc(e); ok {
+			found++
+		}
+	}
+
+	if found == 0 {
+		return
+	}
+
+	if found != len(rhs) || len(lhs) > 0 && len(lhs) != len(rhs) {
+		mg.errorf(stmt.Pos(), "cannot migrate the statement, it mixes the flag functions with other values")
+		return
+	}
+
+	del := mg.delete(src, stmt)
+	for i, e := range rhs {
+		call, argsNumType, _ := mg.defineFunc(e)
+		mg.handled[call.Fun] = true
+
+		o := &migrateOpt{flagOpt: newFlagOpt(call, argsNumType), ptr: argsNumType.ptr < 0}
+		o.field = genFieldName(flagOpt{optName: o.optName})
+		mg.opts = append(mg.opts, o)
+
+		if argsNumType.defVal >= 0 {
+			if def := call.Args[argsNumType.defVal]; !isLiteral(def) {
+				o.defVal = ""
+				o.defExpr = ""
+				del.assign(o, def)
+			}
+		}
+
+		if o.ptr {
+			if len(lhs) > 0 {
+				mg.bindVar(src, o, lhs[i], stmt)
+			}
+			continue
+		}
+
+		//flag.IntVar(&port, ...), port is replaced by the field
+		target, ok := call.Args[argsNumType.ptr].(*ast.UnaryExpr)
+		if !ok || target.Op != token.AND {
+			mg.errorf(call.Pos(), "cannot migrate --%s, the value is not the address of a variable", o.optName)
+			continue
+		}
+
+		obj := mg.bindVar(src, o, target.X, nil)
+		if d := mg.decls[obj]; d != nil && argsNumType.defVal < 0 && d.value != nil {
+			//The value of the variable is the default of flag.Var
+			mg.delete(d.src, d.node).assign(o, d.value)
+		}
+	}
+}
+
+// Replace the variable with the field, the declaration of the variable is deleted
+func (mg *migration) bindVar(src *migrateSrc, o *migrateOpt, e ast.Expr, stmt ast.Node) types.Object {
+	ident, ok := e.(*ast.Ident)
+	if !ok {
+		mg.errorf(e.Pos(), "cannot migrate --%s, %s is not a variable", o.optName, types.ExprString(e))
+		return nil
+	}
+
+	if ident.Name == "_" {
+		return nil
+	}
+
+	obj, ok := mg.info.ObjectOf(ident).(*types.Var)
+	if !ok || obj.IsField() || obj.Pkg() != mg.pkg {
+		mg.errorf(e.Pos(), "cannot migrate --%s, %s is not a variable of the package", o.optName, ident.Name)
+		return nil
+	}
+
+	if mg.vars[obj] != nil {
+		mg.errorf(e.Pos(), "cannot migrate --%s, %s is used by more than one option", o.optName, ident.Name)
+		return nil
+	}
+	mg.vars[obj] = o
+	o.varName = ident.Name
+
+	if len(o.typeName) == 0 {
+		if d := mg.decls[obj]; d != nil {
+			o.typeName = mg.declType(d, ident.Name)
+		}
+	}
+
+	//Such as lvl := level(3), the type is known to the type checker
+	if len(o.typeName) == 0 && obj.Type() != types.Typ[types.Invalid] {
+		o.typeName = types.TypeString(obj.Type(), func(p *types.Package) string {
+			if p == mg.pkg {
+				return ""
+			}
+			return p.Name()
+		})
+	}
+
+	if len(o.typeName) == 0 {
+		mg.errorf(e.Pos(), "cannot migrate --%s, the type of %s is unknown", o.optName, ident.Name)
+	}
+
+	d := mg.decls[obj]
+	if d == nil {
+		mg.errorf(e.Pos(), "cannot migrate --%s, the declaration of %s is not found", o.optName, ident.Name)
+		return obj
+	}
+
+	if d.node != stmt {
+		mg.delete(d.src, d.node)
+	}
+	return obj
+}
+
+// The type of the variable in its declaration
+func (mg *migration) declType(d *migrateDecl, name string) string {
+	var typ ast.Expr
+	switch n := d.node.(type) {
+	case *ast.ValueSpec:
+		typ = n.Type
+	}
+
+	if typ == nil && d.value != nil {
+		typ = typeOfValue(d.value)
+	}
+
+	if typ == nil {
+		return ""
+	}
+	return types.ExprString(typ)
+}
+
+// Whether the default can be written in the default tag
+func isLiteral(e ast.Expr) bool {
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		return true
+	case *ast.CompositeLit:
+		return len(e.Elts) == 0 || len(getJSONValue(e)) > 0
+	}
+	return len(getDefValue(e)) > 0
+}
+
+// Schedule the deletion of the declaration
+func (mg *migration) delete(src *migrateSrc, node ast.Node) *migrateDelete {
+	if del, ok := mg.deletes[node]; ok {
+		return del
+	}
+
+	del := &migrateDelete{src: src, node: node}
+	switch n := node.(type) {
+	case *ast.ValueSpec:
+		_, del.pkgLevel = src.parents[src.parents[n]].(*ast.File)
+		del.names = n.Names
+	case *ast.AssignStmt:
+		for _, l := range n.Lhs {
+			if ident, ok := l.(*ast.Ident); ok {
+				del.names = append(del.names, ident)
+			}
+		}
+	}
+
+	mg.deletes[node] = del
+	mg.order = append(mg.order, del)
+	return del
+}
+
+// Assign the default that is not a literal in place of the declaration
+func (del *migrateDelete) assign(o *migrateOpt, value ast.Expr) {
+	del.assigns = append(del.assigns, migrateAssign{field: o.field, value: value})
+}
+
+// The field names have to be exported identifiers, and the names of the struct and the variable must be free
+func (mg *migration) checkNames() {
+	fields := make(map[string]string)
+	for _, o := range mg.opts {
+		if !token.IsIdentifier(o.field) || !ast.IsExported(o.field) {
+			mg.errorf(token.NoPos, "cannot migrate --%s, %s is not a valid field name", o.optName, o.field)
+		}
+		if opt, ok := fields[o.field]; ok {
+			mg.errorf(token.NoPos, "cannot migrate --%s, the field %s is used by --%s", o.optName, o.field, opt)
+		}
+		fields[o.field] = o.optName
+	}
+
+	if mg.args && len(fields["Args"]) > 0 {
+		mg.errorf(token.NoPos, "cannot migrate flag.Args, the field Args is used by --%s", fields["Args"])
+	}
+
+	for ident, obj := range mg.info.Defs {
+		if obj == nil || obj.Parent() == nil || mg.vars[obj] != nil {
+			continue
+		}
+		if ident.Name == mg.typeName || ident.Name == mg.varName {
+			mg.errorf(ident.Pos(), "%s is already declared, use another name", ident.Name)
+		}
+	}
+}
+
+// Replace the uses of the variables with the fields
+func (mg *migration) rewriteUses() {
+	for _, src := range mg.files {
+		for n, parent := range src.parents {
+			ident, ok := n.(*ast.Ident)
+			if !ok {
+				continue
+			}
+
+			o := mg.vars[mg.info.Uses[ident]]
+			if o == nil {
+				continue
+			}
+
+			field := mg.varName + "." + o.field
+			switch p := parent.(type) {
+			case *ast.StarExpr:
+				if o.ptr {
+					src.edit(p, field)
+					continue
+				}
+			case *ast.SelectorExpr:
+				if o.ptr {
+					src.edit(ident, "(&"+field+")")
+					continue
+				}
+			}
+
+			if o.ptr {
+				field = "&" + field
+			}
+			src.edit(ident, field)
+		}
+	}
+}
+
+// Delete the declarations of the options and the variables.
+// The defaults that are not literals are assigned in their place, at package level they are in the composite literal of the variable.
+// A declaration group is deleted with its last spec
+func (mg *migration) rewriteDecls() {
+	done := make(map[*ast.GenDecl]bool)
+	for _, del := range mg.order {
+		for _, name := range del.names {
+			if obj := mg.info.Defs[name]; name.Name != "_" && obj != nil && mg.vars[obj] == nil {
+				mg.errorf(name.Pos(), "cannot migrate %s, it is declared together with the flag variables", name.Name)
+			}
+		}
+
+		spec, ok := del.node.(*ast.ValueSpec)
+		if !ok {
+			del.replace(del.node, mg.assigns(del))
+			continue
+		}
+
+		decl := del.src.parents[spec].(*ast.GenDecl)
+		if done[decl] {
+			continue
+		}
+
+		if !mg.deletesAll(decl) {
+			//The assignments can not be in the group, they follow it
+			del.src.deleteLines(spec.Pos(), spec.End())
+			if assigns := mg.assigns(del); len(assigns) > 0 {
+				del.src.insert(decl.End(), "\n"+assigns)
+			}
+			continue
+		}
+
+		done[decl] = true
+		var assigns []string
+		for _, spec := range decl.Specs {
+			if a := mg.assigns(mg.deletes[spec]); len(a) > 0 {
+				assigns = append(assigns, a)
+			}
+		}
+		del.replace(decl, strings.Join(assigns, "\n"))
+	}
+
+	mg.deleteEmptyInits()
+}
+
+// Whether every spec of the declaration is deleted
+func (mg *migration) deletesAll(decl *ast.GenDecl) bool {
+	for _, spec := range decl.Specs {
+		if mg.deletes[spec] == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// The assignments of the defaults in place of the declaration, the package level ones go to the variable
+func (mg *migration) assigns(del *migrateDelete) string {
+	var assigns []string
+	for _, a := range del.assigns {
+		text := del.src.textOf(a.value)
+		if del.pkgLevel {
+			mg.inits = append(mg.inits, fmt.Sprintf("%s: %s,", a.field, text))
+			continue
+		}
+		assigns = append(assigns, fmt.Sprintf("%s.%s = %s", mg.varName, a.field, text))
+	}
+	return strings.Join(assigns, "\n")
+}
+
+// Replace the node with the assignments, or delete it
+func (del *migrateDelete) replace(node ast.Node, assigns string) {
+	if len(assigns) > 0 {
+		del.src.edit(node, assigns)
+		return
+	}
+	del.src.deleteLines(node.Pos(), node.End())
+}
+
+// Delete the init functions that only defined the options
+func (mg *migration) deleteEmptyInits() {
+	for _, src := range mg.files {
+		for _, d := range src.file.Decls {
+			fn, ok := d.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || fn.Name.Name != "init" || fn.Body == nil || len(fn.Body.List) == 0 {
+				continue
+			}
+
+			empty := true
+			for _, stmt := range fn.Body.List {
+				if !src.isDeleted(stmt) {
+					empty = false
+					break
+				}
+			}
+
+			if empty {
+				pos := fn.Pos()
+				if fn.Doc != nil {
+					pos = fn.Doc.Pos()
+				}
+				src.deleteLines(pos, fn.End())
+			}
+		}
+	}
+}
+
+// Whether the node is deleted by an edit
+func (src *migrateSrc) isDeleted(n ast.Node) bool {
+	pos, end := src.offset(n.Pos()), src.offset(n.End())
+	for _, e := range src.edits {
+		if len(e.text) == 0 && e.pos <= pos && e.end >= end {
+			return true
+		}
+	}
+	return false
+}
+
+// The functions of the default flag set left in the code do not work without the options
+func (mg *migration) checkLeftovers() {
+	for _, src := range mg.files {
+		ast.Inspect(src.file, func(n ast.Node) bool {
+			e, ok := n.(ast.Expr)
+			if !ok {
+				return true
+			}
+
+			sel, _, ok := mg.flagFunc(e)
+			if !ok || mg.handled[sel] {
+				return true
+			}
+
+			name := sel.Sel.Name
+			if flagSetFuncs[name] || funcName[name].size > 0 || pflagFuncName[name].size > 0 {
+				mg.errorf(sel.Pos(), "cannot migrate %s", types.ExprString(sel))
+			}
+			return true
+		})
+	}
+}
+
+// Insert the struct and the variable after the imports of the file calling flag.Parse
+func (mg *migration) insertStruct() {
+	var code bytes.Buffer
+	code.WriteString(fmt.Sprintf("\n\ntype %s struct {\n", mg.typeName))
+	for _, o := range mg.opts {
+		code.WriteString(fmt.Sprintf("%s %s %s", o.field, o.typeName, genTag(o.flagOpt)))
+	}
+	if mg.args {
+		code.WriteString("Args []string `screw:\"args=args\"`\n")
+	}
+	code.WriteString("}\n\n")
+
+	if len(mg.inits) > 0 {
+		code.WriteString(fmt.Sprintf("var %s = %s{\n%s\n}\n", mg.varName, mg.typeName, strings.Join(mg.inits, "\n")))
+	} else {
+		code.WriteString(fmt.Sprintf("var %s %s\n", mg.varName, mg.typeName))
+	}
+
+	f := mg.parse.file
+	pos := f.Name.End()
+	for _, d := range f.Decls {
+		if gen, ok := d.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			pos = gen.End()
+		}
+	}
+
+	off := mg.parse.offset(pos)
+	mg.parse.edits = append(mg.parse.edits, migrateEdit{pos: off, end: off, text: code.String()})
+}
+
+// Apply the edits, fix the imports and format the changed files
+func (mg *migration) output() ([]MigrateFile, error) {
+	paths := make(map[string]string)
+	for k, v := range migrateImportPath {
+		paths[k] = v
+	}
+	for _, src := range mg.files {
+		for _, imp := range src.file.Imports {
+			if path, err := strconv.Unquote(imp.Path.Value); err == nil {
+				paths[importSpecName(imp, path)] = path
+			}
+		}
+	}
+
+	var files []MigrateFile
+	for _, src := range mg.files {
+		if len(src.edits) == 0 {
+			continue
+		}
+
+		code, err := applyEdits(src.src, 0, src.edits)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", src.name, err)
+		}
+
+		if code, err = mg.fixImports(src, code, paths); err != nil {
+			return nil, fmt.Errorf("%s: %s", src.name, err)
+		}
+
+		if code, err = format.Source(code); err != nil {
+			return nil, fmt.Errorf("%s: %s", src.name, err)
+		}
+
+		files = append(files, MigrateFile{Name: src.name, Src: src.src, Dst: code})
+	}
+	return files, nil
+}
+
+func importSpecName(imp *ast.ImportSpec, path string) string {
+	if imp.Name != nil {
+		return imp.Name.Name
+	}
+	return importName(path)
+}
+
+// The names of the packages the file refers to
+func usedPackages(f *ast.File) map[string]bool {
+	used := make(map[string]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok && x.Obj == nil {
+				used[x.Name] = true
+			}
+		}
+		return true
+	})
+	return used
+}
+
+// Delete the imports that are not used after the migration, and add the imports the new code needs
+func (mg *migration) fixImports(src *migrateSrc, code []byte, paths map[string]string) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, src.name, code, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	before := usedPackages(src.file)
+	after := usedPackages(f)
+
+	imported := make(map[string]bool)
+	for _, imp := range f.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		imported[importSpecName(imp, path)] = true
+	}
+
+	var adds []string
+	for name := range after {
+		if imported[name] || name == mg.varName || mg.pkg.Scope().Lookup(name) != nil {
+			continue
+		}
+		if path, ok := paths[name]; ok {
+			adds = append(adds, strconv.Quote(path))
+		}
+	}
+	sort.Strings(adds)
+
+	out := &migrateSrc{src: code}
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+
+	var lparen, rparen token.Pos
+	for _, d := range f.Decls {
+		decl, ok := d.(*ast.GenDecl)
+		if !ok || decl.Tok != token.IMPORT {
+			continue
+		}
+
+		if decl.Lparen.IsValid() && !lparen.IsValid() {
+			lparen, rparen = decl.Lparen, decl.Rparen
+		}
+
+		for _, spec := range decl.Specs {
+			imp := spec.(*ast.ImportSpec)
+			path, _ := strconv.Unquote(imp.Path.Value)
+			name := importSpecName(imp, path)
+			if name == "_" || name == "." || !before[name] || after[name] {
+				continue
+			}
+
+			switch {
+			case decl.Lparen.IsValid():
+				out.deleteLinesAt(offset(imp.Pos()), offset(imp.End()))
+			case len(adds) > 0:
+				out.edits = append(out.edits, migrateEdit{pos: offset(imp.Pos()), end: offset(imp.End()), text: adds[0]})
+				adds = adds[1:]
+			default:
+				out.deleteLinesAt(offset(decl.Pos()), offset(decl.End()))
+			}
+		}
+	}
+
+	switch {
+	case len(adds) == 0:
+	case lparen.IsValid():
+		//The standard packages are added to the first group, the others to a new group at the end
+		var std, other []string
+		for _, path := range adds {
+			if strings.Contains(path, ".") {
+				other = append(other, path)
+			} else {
+				std = append(std, path)
+			}
+		}
+
+		pos := offset(lparen) + 1
+		out.edits = append(out.edits, migrateEdit{pos: pos, end: pos, text: "\n" + strings.Join(std, "\n")})
+		if len(other) > 0 {
+			pos = offset(rparen)
+			out.edits = append(out.edits, migrateEdit{pos: pos, end: pos, text: "\n" + strings.Join(other, "\n") + "\n"})
+		}
+	default:
+		pos := offset(f.Name.End())
+		out.edits = append(out.edits, migrateEdit{pos: pos, end: pos, text: "\n\nimport (\n" + strings.Join(adds, "\n") + "\n)"})
+	}
+
+	return applyEdits(code, 0, out.edits)
+}
+
+func (src *migrateSrc) offset(pos token.Pos) int {
+	return src.fset.Position(pos).Offset
+}
+
+// Replace the node with text
+func (src *migrateSrc) edit(n ast.Node, text string) {
+	src.edits = append(src.edits, migrateEdit{pos: src.offset(n.Pos()), end: src.offset(n.End()), text: text})
+}
+
+// Insert text at the position
+func (src *migrateSrc) insert(pos token.Pos, text string) {
+	off := src.offset(pos)
+	src.edits = append(src.edits, migrateEdit{pos: off, end: off, text: text})
+}
+
+// Delete the node, the lines are deleted when nothing else is on them
+func (src *migrateSrc) deleteLines(pos, end token.Pos) {
+	src.deleteLinesAt(src.offset(pos), src.offset(end))
+}
+
+func (src *migrateSrc) deleteLinesAt(pos, end int) {
+	start := pos
+	for start > 0 && (src.src[start-1] == ' ' || src.src[start-1] == '\t') {
+		start--
+	}
+
+	if start == 0 || src.src[start-1] == '\n' {
+		stop := end
+		for stop < len(src.src) && (src.src[stop] == ' ' || src.src[stop] == '\t') {
+			stop++
+		}
+		if stop == len(src.src) || src.src[stop] == '\n' {
+			pos, end = start, stop
+			if end < len(src.src) {
+				end++
+			}
+		}
+	}
+
+	src.edits = append(src.edits, migrateEdit{pos: pos, end: end})
+}
+
+// The source of the node with the edits inside it
+func (src *migrateSrc) textOf(n ast.Node) string {
+	pos, end := src.offset(n.Pos()), src.offset(n.End())
+
+	var inner []migrateEdit
+	for _, e := range src.edits {
+		if e.pos >= pos && e.end <= end {
+			inner = append(inner, e)
+		}
+	}
+
+	text, err := applyEdits(src.src[pos:end], pos, inner)
+	if err != nil {
+		return string(src.src[pos:end])
+	}
+	return string(text)
+}
+
+// Apply the edits to code that starts at the offset base, the edits inside a replaced range are dropped
+func applyEdits(code []byte, base int, edits []migrateEdit) ([]byte, error) {
+	edits = append([]migrateEdit(nil), edits...)
+	sort.SliceStable(edits, func(i, j int) bool {
+		if edits[i].pos != edits[j].pos {
+			return edits[i].pos < edits[j].pos
+		}
+		return edits[i].end > edits[j].end
+	})
+
+	var out bytes.Buffer
+	last := base
+	for _, e := range edits {
+		if e.pos < last {
+			if e.end <= last {
+				continue
+			}
+			return nil, fmt.Errorf("overlapping changes at offset %d", e.pos)
+		}
+
+		out.Write(code[last-base : e.pos-base])
+		out.WriteString(e.text)
+		last = e.end
+	}
+	out.Write(code[last-base:])
+	return out.Bytes(), nil
+}
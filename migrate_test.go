@@ -0,0 +1,94 @@
+package screw
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_Migrate(t *testing.T) {
+	for _, name := range []string{"pointer", "group", "init"} {
+		dir := filepath.Join("testdata", "migrate", name)
+		files, err := NewMigrate(dir).Run()
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+
+		if len(files) != 1 {
+			t.Errorf("%s: got %d files", name, len(files))
+			continue
+		}
+
+		need, err := ioutil.ReadFile(dir + ".golden")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(files[0].Dst) != string(need) {
+			t.Errorf("%s: got\n%s\nneed\n%s", name, files[0].Dst, need)
+		}
+	}
+}
+
+func Test_Migrate_Names(t *testing.T) {
+	dir := t.TempDir()
+	src := "package main\nimport \"flag\"\nfunc main() {\n\tflag.Int(\"port\", 1, \"\")\n\tflag.Parse()\n}\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := NewMigrate(dir).SetTypeName("options").SetVarName("opts").Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{"type options struct", "var opts options", "screw.MustBind(&opts)"} {
+		if !strings.Contains(string(files[0].Dst), s) {
+			t.Errorf("the code does not contain %q:\n%s", s, files[0].Dst)
+		}
+	}
+}
+
+func Test_Migrate_Error(t *testing.T) {
+	for _, test := range []struct {
+		src string
+		msg string
+	}{
+		{
+			src: "package main\nimport \"flag\"\nfunc main() {\n\tport, n := flag.Int(\"port\", 1, \"\"), 2\n\tflag.Parse()\n\t_, _ = port, n\n}\n",
+			msg: "it mixes the flag functions with other values",
+		},
+		{
+			src: "package main\nimport \"flag\"\nfunc main() {\n\tflag.Int(\"port\", 1, \"\")\n\tflag.Parse()\n\tflag.Lookup(\"port\")\n}\n",
+			msg: "cannot migrate flag.Lookup",
+		},
+		{
+			src: "package main\nimport \"flag\"\nvar port, n = 1, 2\nfunc main() {\n\tflag.IntVar(&port, \"port\", 1, \"\")\n\tflag.Parse()\n}\n",
+			msg: "cannot migrate n, it is declared together with the flag variables",
+		},
+		{
+			src: "package main\nimport \"flag\"\nvar cfg int\nfunc main() {\n\tflag.Int(\"port\", 1, \"\")\n\tflag.Parse()\n}\n",
+			msg: "cfg is already declared",
+		},
+		{
+			src: "package main\nimport \"flag\"\nfunc main() {\n\tflag.Int(\"port\", 1, \"\")\n}\n",
+			msg: "flag.Parse is not called",
+		},
+		{
+			src: "package main\nfunc main() {}\n",
+			msg: "no flag code found",
+		},
+	} {
+		dir := t.TempDir()
+		if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(test.src), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := NewMigrate(dir).Run()
+		if err == nil || !strings.Contains(err.Error(), test.msg) {
+			t.Errorf("got %v, need %q\n%s", err, test.msg, test.src)
+		}
+	}
+}
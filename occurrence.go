@@ -0,0 +1,68 @@
+package screw
+
+import (
+	"sort"
+	"strings"
+)
+
+// Occurrence is one option or positional argument that set a value, see Binder.Occurrences
+type Occurrence struct {
+	//SourceFlag or SourcePositional
+	Kind SourceKind
+	//The Go field name of the option, the same for all the names of the option, such as Serve.Port
+	Field string
+	//The name of the option as written or the args name, such as -p, --port or <files>
+	Name string
+	//The argument of the option as written, such as -ltr or --port=80, the value itself for the positional arguments
+	Arg   string
+	Value string
+	//The index of the argument holding the value in the parsed arguments
+	Index int
+	//The position of the short option in Arg, such as 2 for t of -ltr, 0 for the others
+	Offset int
+}
+
+// Record the option or the positional argument that set the value
+func (c *cmdParser) record(o *optionState, kind SourceKind, name string, arg string, value string, index int, offset int) {
+	o.setSource(kind, value, name, c.base+index)
+	if o.secret && len(value) > 0 {
+		arg = strings.Replace(arg, value, redacted, -1)
+		value = redacted
+	}
+	c.occurrences = append(c.occurrences, Occurrence{
+		Kind:   kind,
+		Field:  c.cmd.fieldName(o.field),
+		Name:   name,
+		Arg:    arg,
+		Value:  value,
+		Index:  c.base + index,
+		Offset: offset,
+	})
+}
+
+// Return every option and positional argument that set a value, in the order of the command line
+func (b *Binder) Occurrences() []Occurrence {
+	occurrences := make([]Occurrence, len(b.occurrences))
+	copy(occurrences, b.occurrences)
+
+	//The positional arguments are bound after the options of their command
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		if occurrences[i].Index != occurrences[j].Index {
+			return occurrences[i].Index < occurrences[j].Index
+		}
+		return occurrences[i].Offset < occurrences[j].Offset
+	})
+	return occurrences
+}
+
+// See Binder.Occurrences
+func (c *Screw) Occurrences() []Occurrence {
+	if c.binder == nil {
+		return nil
+	}
+	return c.binder.Occurrences()
+}
+
+func Occurrences() []Occurrence {
+	return CommandLine.Occurrences()
+}
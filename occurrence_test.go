@@ -0,0 +1,54 @@
+package screw
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_Occurrences(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		need []Occurrence
+	}{
+		{
+			args: nil,
+			need: []Occurrence{},
+		},
+		{
+			//The names of one option share the field
+			args: []string{"-n", "x", "--name=y"},
+			need: []Occurrence{
+				{Kind: SourceFlag, Field: "Name", Name: "-n", Arg: "-n", Value: "x", Index: 1, Offset: 1},
+				{Kind: SourceFlag, Field: "Name", Name: "--name", Arg: "--name=y", Value: "y", Index: 2},
+			},
+		},
+		{
+			args: []string{"-dl5", "a"},
+			need: []Occurrence{
+				{Kind: SourceFlag, Field: "Debug", Name: "-d", Arg: "-dl5", Value: "true", Index: 0, Offset: 1},
+				{Kind: SourceFlag, Field: "Level", Name: "-l", Arg: "-dl5", Value: "5", Index: 0, Offset: 2},
+				{Kind: SourcePositional, Field: "Files", Name: "<files>", Arg: "a", Value: "a", Index: 1},
+			},
+		},
+		{
+			//The positional arguments are in the order of the command line
+			args: []string{"a", "-d", "b", "serve", "-p", "1"},
+			need: []Occurrence{
+				{Kind: SourcePositional, Field: "Files", Name: "<files>", Arg: "a", Value: "a", Index: 0},
+				{Kind: SourceFlag, Field: "Debug", Name: "-d", Arg: "-d", Value: "true", Index: 1, Offset: 1},
+				{Kind: SourcePositional, Field: "Files", Name: "<files>", Arg: "b", Value: "b", Index: 2},
+				{Kind: SourceFlag, Field: "Serve.Port", Name: "-p", Arg: "-p", Value: "1", Index: 5, Offset: 1},
+			},
+		},
+	} {
+		var got sourceConfig
+		s := New(test.args).SetExit(false).SetEnviron([]string{"LEVEL=3"})
+		if err := s.Bind(&got); err != nil {
+			t.Fatalf("%q: %v", test.args, err)
+		}
+
+		if occurrences := s.Occurrences(); !reflect.DeepEqual(occurrences, test.need) {
+			t.Errorf("%q: got\n%+v\nneed\n%+v", test.args, occurrences, test.need)
+		}
+	}
+}
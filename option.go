@@ -6,7 +6,7 @@ import (
 	"github.com/antlabs/strsim"
 )
 
-func (c *Screw) maybeOpt(optionName string) string {
+func (c *command) maybeOpt(optionName string) string {
 	opts := make([]string, len(c.shortAndLong))
 	index := 0
 	for k := range c.shortAndLong {
@@ -27,7 +27,7 @@ func (c *Screw) maybeOpt(optionName string) string {
 	return ""
 }
 
-func (c *Screw) genMaybeHelpMsg(optionName string) string {
+func (c *command) genMaybeHelpMsg(optionName string) string {
 	if s := c.maybeOpt(optionName); len(s) > 0 {
 		return fmt.Sprintf("\n	Did you mean --%s?\n", s)
 	}
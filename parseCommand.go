@@ -0,0 +1,543 @@
+package screw
+
+import (
+	"go/ast"
+	"strconv"
+	"strings"
+)
+
+// The import paths of the command libraries
+var commandImportPath = map[string]string{
+	"github.com/spf13/cobra":   "cobra",
+	"github.com/urfave/cli":    "cli",
+	"github.com/urfave/cli/v2": "cli",
+	"gopkg.in/urfave/cli.v1":   "cli",
+	"gopkg.in/urfave/cli.v2":   "cli",
+}
+
+// The value types of the flags of urfave/cli
+var cliFlagTypes = map[string]string{
+	"BoolFlag":         "bool",
+	"DurationFlag":     "time.Duration",
+	"Float64Flag":      "float64",
+	"Float64SliceFlag": "[]float64",
+	"GenericFlag":      "string",
+	"Int64Flag":        "int64",
+	"Int64SliceFlag":   "[]int64",
+	"IntFlag":          "int",
+	"IntSliceFlag":     "[]int",
+	"PathFlag":         "string",
+	"StringFlag":       "string",
+	"StringSliceFlag":  "[]string",
+	"Uint64Flag":       "uint64",
+	"UintFlag":         "uint",
+}
+
+// A command of cobra or urfave/cli, it becomes a struct and its subcommands become the fields
+type commandDef struct {
+	varName string
+	use     string
+	usage   string
+	args    []flagOpt
+	subs    []*commandDef
+	isSub   bool
+}
+
+// The name of the subcommand, the first word of cobra's Use
+func (d *commandDef) name() string {
+	if fields := strings.Fields(d.use); len(fields) > 0 {
+		return fields[0]
+	}
+	return d.varName
+}
+
+func (d *commandDef) findOpt(name string) *flagOpt {
+	for i := range d.args {
+		if d.args[i].optName == name {
+			return &d.args[i]
+		}
+	}
+	return nil
+}
+
+// The calls resolved when the whole file is inspected
+type addCommand struct {
+	parent *ast.Object
+	subs   []ast.Expr
+}
+
+// Find the import names of cobra and urfave/cli
+func (p *ParseFlag) findCommandImports() {
+	for _, imp := range p.astFile.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		lib, ok := commandImportPath[path]
+		if !ok {
+			continue
+		}
+
+		name := lib
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+
+		if lib == "cobra" {
+			p.cobra = name
+		} else {
+			p.cli = name
+		}
+	}
+}
+
+// The composite literal of the type, &T{} is also accepted
+func compositeOf(e ast.Expr, pkg string, typeName string) (*ast.CompositeLit, bool) {
+	if u, ok := e.(*ast.UnaryExpr); ok {
+		e = u.X
+	}
+
+	lit, ok := e.(*ast.CompositeLit)
+	if !ok || len(pkg) == 0 {
+		return nil, false
+	}
+
+	if lit.Type == nil {
+		return lit, len(typeName) == 0
+	}
+	return lit, isFunc(lit.Type, pkg, typeName)
+}
+
+// The object of the identifier, nil if it is not a local or package-level variable of the file
+func objectOf(e ast.Expr) *ast.Object {
+	ident, ok := e.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	return ident.Obj
+}
+
+// The value of the string literal
+func stringOf(e ast.Expr) string {
+	lit, ok := e.(*ast.BasicLit)
+	if !ok {
+		return ""
+	}
+
+	v, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return ""
+	}
+	return v
+}
+
+// The values of the string slice literal
+func stringsOf(e ast.Expr) []string {
+	lit, ok := e.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+
+	var s []string
+	for _, elt := range lit.Elts {
+		if v := stringOf(elt); len(v) > 0 {
+			s = append(s, v)
+		}
+	}
+	return s
+}
+
+// Collect the commands of cobra and urfave/cli
+func (p *ParseFlag) findCommands() {
+	p.findCommandImports()
+	if len(p.cobra) == 0 && len(p.cli) == 0 {
+		return
+	}
+
+	p.commandVars = make(map[*ast.Object]*commandDef)
+	p.commandLits = make(map[*ast.CompositeLit]*commandDef)
+	p.persistentSets = make(map[*ast.Object]bool)
+	flagSets := make(map[*ast.Object]*commandDef)
+	var adds []addCommand
+
+	ast.Inspect(p.astFile, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			p.assignCommands(n.Lhs, n.Rhs, flagSets)
+		case *ast.ValueSpec:
+			lhs := make([]ast.Expr, len(n.Names))
+			for i, name := range n.Names {
+				lhs[i] = name
+			}
+			p.assignCommands(lhs, n.Values, flagSets)
+		case *ast.CompositeLit:
+			if _, ok := compositeOf(n, p.cobra, "Command"); ok {
+				p.cobraCommand(n)
+			}
+			if _, ok := compositeOf(n, p.cli, "App"); ok {
+				p.cliCommand(n, false)
+			}
+		case *ast.CallExpr:
+			sel, ok := n.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+
+			switch sel.Sel.Name {
+			case "AddCommand":
+				if obj := objectOf(sel.X); obj != nil {
+					adds = append(adds, addCommand{parent: obj, subs: n.Args})
+				}
+			case "MarkFlagRequired", "MarkPersistentFlagRequired":
+				if d := p.commandVars[objectOf(sel.X)]; d != nil && len(n.Args) == 1 {
+					if opt := d.findOpt(stringOf(n.Args[0])); opt != nil {
+						opt.required = true
+					}
+				}
+			default:
+				p.cobraFlag(n, sel, flagSets)
+			}
+		}
+		return true
+	})
+
+	for _, add := range adds {
+		parent := p.commandVars[add.parent]
+		if parent == nil {
+			continue
+		}
+
+		for _, e := range add.subs {
+			if sub := p.resolveCommand(e); sub != nil {
+				sub.isSub = true
+				parent.subs = append(parent.subs, sub)
+			}
+		}
+	}
+}
+
+// Record the variables of the commands and the flag sets, such as cmd := &cobra.Command{} or flags := cmd.Flags()
+func (p *ParseFlag) assignCommands(lhs []ast.Expr, rhs []ast.Expr, flagSets map[*ast.Object]*commandDef) {
+	if len(lhs) != len(rhs) {
+		return
+	}
+
+	for i, e := range rhs {
+		obj := objectOf(lhs[i])
+
+		//app.Flags = []cli.Flag{...}, app.Name = "name"
+		if sel, ok := lhs[i].(*ast.SelectorExpr); ok {
+			if d := p.commandVars[objectOf(sel.X)]; d != nil {
+				p.cliField(d, sel.Sel.Name, e)
+			}
+			continue
+		}
+
+		if obj == nil {
+			continue
+		}
+
+		if lit, ok := compositeOf(e, p.cobra, "Command"); ok {
+			d := p.cobraCommand(lit)
+			d.varName = obj.Name
+			p.commandVars[obj] = d
+			continue
+		}
+
+		if lit, ok := compositeOf(e, p.cli, "App"); ok {
+			d := p.cliCommand(lit, false)
+			d.varName = obj.Name
+			p.commandVars[obj] = d
+			continue
+		}
+
+		if lit, ok := compositeOf(e, p.cli, "Command"); ok {
+			d := p.cliCommand(lit, true)
+			d.varName = obj.Name
+			p.commandVars[obj] = d
+			continue
+		}
+
+		call, ok := e.(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+
+		//app := cli.NewApp()
+		if isFunc(call.Fun, p.cli, "NewApp") && len(p.cli) > 0 {
+			d := &commandDef{varName: obj.Name}
+			p.commandVars[obj] = d
+			p.commands = append(p.commands, d)
+			continue
+		}
+
+		//flags := cmd.Flags()
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok && isFlagSetFunc(sel.Sel.Name) {
+			if d := p.commandVars[objectOf(sel.X)]; d != nil {
+				flagSets[obj] = d
+				p.persistentSets[obj] = sel.Sel.Name == "PersistentFlags"
+			}
+		}
+	}
+}
+
+func isFlagSetFunc(name string) bool {
+	return name == "Flags" || name == "PersistentFlags" || name == "LocalFlags"
+}
+
+func (p *ParseFlag) cobraCommand(lit *ast.CompositeLit) *commandDef {
+	if d, ok := p.commandLits[lit]; ok {
+		return d
+	}
+
+	d := &commandDef{}
+	p.commandLits[lit] = d
+	p.commands = append(p.commands, d)
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+
+		switch getIdentName(kv.Key) {
+		case "Use":
+			d.use = stringOf(kv.Value)
+		case "Short":
+			d.usage = stringOf(kv.Value)
+		case "Long":
+			if len(d.usage) == 0 {
+				d.usage = stringOf(kv.Value)
+			}
+		}
+	}
+	return d
+}
+
+// The flag functions of cobra, such as cmd.Flags().StringVarP(...) or flags.StringVarP(...)
+func (p *ParseFlag) cobraFlag(call *ast.CallExpr, sel *ast.SelectorExpr, flagSets map[*ast.Object]*commandDef) {
+	var d *commandDef
+	persistent := false
+	switch x := sel.X.(type) {
+	case *ast.Ident:
+		d = flagSets[x.Obj]
+		persistent = p.persistentSets[x.Obj]
+	case *ast.CallExpr:
+		if fn, ok := x.Fun.(*ast.SelectorExpr); ok && isFlagSetFunc(fn.Sel.Name) {
+			d = p.commandVars[objectOf(fn.X)]
+			persistent = fn.Sel.Name == "PersistentFlags"
+		}
+	}
+
+	if d == nil {
+		return
+	}
+
+	argsNumType, ok := pflagFuncName[sel.Sel.Name]
+	if !ok || argsNumType.size != len(call.Args) {
+		return
+	}
+	opt := newFlagOpt(call, argsNumType)
+	opt.persistent = persistent
+	d.args = append(d.args, opt)
+}
+
+// The command of the expression, a variable, a literal or a function returning a command
+func (p *ParseFlag) resolveCommand(e ast.Expr) *commandDef {
+	if d := p.commandVars[objectOf(e)]; d != nil {
+		return d
+	}
+
+	if lit, ok := compositeOf(e, p.cobra, "Command"); ok {
+		return p.cobraCommand(lit)
+	}
+
+	//newServeCmd() returns the command
+	call, ok := e.(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+
+	obj := objectOf(call.Fun)
+	if obj == nil {
+		return nil
+	}
+
+	fn, ok := obj.Decl.(*ast.FuncDecl)
+	if !ok || fn.Body == nil {
+		return nil
+	}
+
+	var d *commandDef
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if ret, ok := n.(*ast.ReturnStmt); ok && len(ret.Results) == 1 && d == nil {
+			if _, ok := ret.Results[0].(*ast.CallExpr); !ok {
+				d = p.resolveCommand(ret.Results[0])
+			}
+		}
+		return d == nil
+	})
+	return d
+}
+
+// The App or Command literal of urfave/cli
+func (p *ParseFlag) cliCommand(lit *ast.CompositeLit, isSub bool) *commandDef {
+	if d, ok := p.commandLits[lit]; ok {
+		return d
+	}
+
+	d := &commandDef{isSub: isSub}
+	p.commandLits[lit] = d
+	p.commands = append(p.commands, d)
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			p.cliField(d, getIdentName(kv.Key), kv.Value)
+		}
+	}
+	return d
+}
+
+// Set the field of the App or Command of urfave/cli
+func (p *ParseFlag) cliField(d *commandDef, name string, value ast.Expr) {
+	switch name {
+	case "Name":
+		d.use = stringOf(value)
+	case "Usage":
+		d.usage = stringOf(value)
+	case "Flags":
+		lit, ok := value.(*ast.CompositeLit)
+		if !ok {
+			return
+		}
+		for _, elt := range lit.Elts {
+			if opt, ok := p.cliFlag(elt); ok {
+				d.args = append(d.args, opt)
+			}
+		}
+	case "Commands", "Subcommands":
+		lit, ok := value.(*ast.CompositeLit)
+		if !ok {
+			return
+		}
+		for _, elt := range lit.Elts {
+			if sub, ok := compositeOf(elt, p.cli, "Command"); ok {
+				d.subs = append(d.subs, p.cliCommand(sub, true))
+			} else if sub, ok := compositeOf(elt, p.cli, ""); ok {
+				//The type of the element is elided
+				d.subs = append(d.subs, p.cliCommand(sub, true))
+			} else if sub := p.commandVars[objectOf(elt)]; sub != nil {
+				sub.isSub = true
+				d.subs = append(d.subs, sub)
+			}
+		}
+	}
+}
+
+// The flag of urfave/cli, such as &cli.StringFlag{Name: "port", Aliases: []string{"p"}, EnvVars: []string{"PORT"}}
+func (p *ParseFlag) cliFlag(e ast.Expr) (opt flagOpt, ok bool) {
+	if u, isUnary := e.(*ast.UnaryExpr); isUnary {
+		e = u.X
+	}
+
+	lit, isLit := e.(*ast.CompositeLit)
+	if !isLit {
+		return opt, false
+	}
+
+	sel, isSel := lit.Type.(*ast.SelectorExpr)
+	if !isSel || !isIdent(sel.X, p.cli) {
+		return opt, false
+	}
+
+	if opt.typeName, ok = cliFlagTypes[sel.Sel.Name]; !ok {
+		return opt, false
+	}
+
+	if strings.HasSuffix(sel.Sel.Name, "SliceFlag") {
+		opt.sep = defaultSep
+	}
+
+	var names []string
+	for _, elt := range lit.Elts {
+		kv, isKV := elt.(*ast.KeyValueExpr)
+		if !isKV {
+			continue
+		}
+
+		switch getIdentName(kv.Key) {
+		case "Name":
+			//urfave/cli v1 writes the aliases in the name, such as "port, p"
+			for _, name := range strings.Split(stringOf(kv.Value), ",") {
+				if name = strings.TrimSpace(name); len(name) > 0 {
+					names = append(names, name)
+				}
+			}
+		case "Aliases":
+			names = append(names, stringsOf(kv.Value)...)
+		case "Usage":
+			opt.usage = stringOf(kv.Value)
+		case "Value":
+			opt.setDefValue(kv.Value, cliDefValue(kv.Value))
+		case "EnvVars":
+			opt.setEnv(stringsOf(kv.Value))
+		case "EnvVar":
+			//urfave/cli v1 writes the env vars in one string, such as "PORT, APP_PORT"
+			var envs []string
+			for _, env := range strings.Split(stringOf(kv.Value), ",") {
+				if env = strings.TrimSpace(env); len(env) > 0 {
+					envs = append(envs, env)
+				}
+			}
+			opt.setEnv(envs)
+		case "Required":
+			opt.required = isIdent(kv.Value, "true")
+		case "Destination":
+			opt.varName = getPtrArgName(kv.Value)
+		}
+	}
+
+	for _, name := range names {
+		switch {
+		case len(opt.optName) == 0:
+			opt.optName = name
+		case len(name) == 1 && len(opt.short) == 0:
+			opt.short = name
+		default:
+			opt.long = append(opt.long, name)
+		}
+	}
+
+	return opt, len(opt.optName) > 0
+}
+
+// An option reads one env, the other env vars are noted in the comment of the field
+func (opt *flagOpt) setEnv(envs []string) {
+	if len(envs) == 0 {
+		return
+	}
+
+	opt.env = envs[0]
+	if len(envs) > 1 {
+		opt.notes = append(opt.notes, "EnvVars: "+strings.Join(envs[1:], ", ")+" not migrated")
+	}
+}
+
+// The slice constructors of urfave/cli, such as cli.NewStringSlice
+func isCliSlice(call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	return ok && strings.HasPrefix(sel.Sel.Name, "New") && strings.HasSuffix(sel.Sel.Name, "Slice")
+}
+
+// The default of urfave/cli, the slices are also created by cli.NewStringSlice("a", "b")
+func cliDefValue(e ast.Expr) string {
+	call, ok := e.(*ast.CallExpr)
+	if !ok || !isCliSlice(call) {
+		return getDefValue(e)
+	}
+
+	if len(call.Args) == 0 {
+		return ""
+	}
+
+	return getJSONValue(&ast.CompositeLit{Elts: call.Args})
+}
@@ -0,0 +1,147 @@
+package screw
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func Test_ParseCommand(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		src  string
+		need string
+	}{
+		{
+			name: "cobra",
+			src: `package main
+import "github.com/spf13/cobra"
+var rootCmd = &cobra.Command{Use: "app", Short: "the app"}
+
+func main() {
+	serveCmd := &cobra.Command{Use: "serve [flags]", Short: "start the server"}
+	serveCmd.Flags().IntP("port", "p", 8080, "listen port")
+	var debug bool
+	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "d", false, "debug")
+	serveCmd.Flags().String("host", "", "host")
+	serveCmd.MarkFlagRequired("host")
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.Execute()
+}`,
+			need: "type serveAutoGen struct {\n" +
+				"	Port int    `screw:\"-p;--port\" default:\"8080\" usage:\"listen port\" `\n" +
+				"	Host string `screw:\"--host\" usage:\"host\" valid:\"required\" `\n" +
+				"}\n" +
+				"type appAutoGen struct {\n" +
+				"	Debug bool         `screw:\"-d;--debug\" default:\"false\" usage:\"debug\" ` // PersistentFlags: not inherited by the subcommands\n" +
+				"	Serve serveAutoGen `screw:\"subcommand=serve\" usage:\"start the server\"`\n" +
+				"}\n",
+		},
+		{
+			name: "urfave/cli",
+			src: `package main
+import "github.com/urfave/cli/v2"
+func main() {
+	app := &cli.App{
+		Name: "app",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "lang", Aliases: []string{"l", "language"}, Value: "en", Usage: "language", EnvVars: []string{"APP_LANG"}},
+		},
+		Commands: []*cli.Command{
+			{
+				Name:  "add",
+				Usage: "add a task",
+				Flags: []cli.Flag{&cli.IntFlag{Name: "n", Required: true}},
+			},
+		},
+	}
+	app.Run(nil)
+}`,
+			need: "type addAutoGen struct {\n" +
+				"	N int `screw:\"-n\" valid:\"required\" `\n" +
+				"}\n" +
+				"type appAutoGen struct {\n" +
+				"	Lang string     `screw:\"-l;--lang;--language;env=APP_LANG\" default:\"en\" usage:\"language\" `\n" +
+				"	Add  addAutoGen `screw:\"subcommand=add\" usage:\"add a task\"`\n" +
+				"}\n",
+		},
+		{
+			//An option reads one env, the others are noted
+			name: "EnvVars",
+			src: `package main
+import "github.com/urfave/cli/v2"
+func main() {
+	app := &cli.App{
+		Name: "app",
+		Flags: []cli.Flag{
+			&cli.IntFlag{Name: "port", EnvVars: []string{"PORT", "APP_PORT"}},
+			&cli.StringFlag{Name: "host", EnvVar: "HOST, APP_HOST, ADDR"},
+		},
+	}
+	app.Run(nil)
+}`,
+			need: "type appAutoGen struct {\n" +
+				"	Port int    `screw:\"--port;env=PORT\" ` // EnvVars: APP_PORT not migrated\n" +
+				"	Host string `screw:\"--host;env=HOST\" ` // EnvVars: APP_HOST, ADDR not migrated\n" +
+				"}\n",
+		},
+		{
+			//The required bool would have to be true, the default always satisfies required
+			name: "Required",
+			src: `package main
+import "github.com/urfave/cli/v2"
+func main() {
+	app := &cli.App{
+		Name: "app",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "dry", Required: true},
+			&cli.IntFlag{Name: "port", Value: 80, Required: true},
+			&cli.IntFlag{Name: "n", Value: 0, Required: true},
+		},
+	}
+	app.Run(nil)
+}`,
+			need: "type appAutoGen struct {\n" +
+				"	Dry  bool `screw:\"--dry\" `               // Required: not migrated, a required bool would have to be true\n" +
+				"	Port int  `screw:\"--port\" default:\"80\" ` // Required: not migrated, the default \"80\" always satisfies it\n" +
+				"	N    int  `screw:\"-n\" default:\"0\" valid:\"required\" `\n" +
+				"}\n",
+		},
+		{
+			//The persistent flags of a command without subcommands are not noted
+			name: "PersistentFlags",
+			src: `package main
+import "github.com/spf13/cobra"
+func main() {
+	root := &cobra.Command{Use: "app"}
+	flags := root.PersistentFlags()
+	flags.Bool("v", false, "verbose")
+	sub := &cobra.Command{Use: "sub"}
+	sub.PersistentFlags().Int("n", 1, "n")
+	root.AddCommand(sub)
+	root.Execute()
+}`,
+			need: "type subAutoGen struct {\n" +
+				"	N int `screw:\"-n\" default:\"1\" usage:\"n\" `\n" +
+				"}\n" +
+				"type appAutoGen struct {\n" +
+				"	V   bool       `screw:\"-v\" default:\"false\" usage:\"verbose\" ` // PersistentFlags: not inherited by the subcommands\n" +
+				"	Sub subAutoGen `screw:\"subcommand=sub\"`\n" +
+				"}\n",
+		},
+	} {
+		code, err := NewParseFlag().FromSource("main.go", test.src).OnlyStruct().Parse()
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if string(code) != test.need {
+			t.Errorf("%s: got\n%q\nneed\n%q", test.name, code, test.need)
+		}
+
+		if _, err := parser.ParseFile(token.NewFileSet(), "", "package main\n"+string(code), 0); err != nil {
+			t.Errorf("%s: the code does not compile: %v", test.name, err)
+		}
+	}
+}
@@ -1,46 +1,164 @@
 package screw
 
 import (
+	"bytes"
+	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"go/types"
+	"sort"
 	"strconv"
+	"strings"
 )
 
-// Name of the parsing function of the flag library, white name
+// The layout of the arguments of a flag function, -1 means there is no such argument
 type argsNumAndType struct {
 	size     int
 	typeName string
+	sep      string
+	ptr      int
+	name     int
+	short    int
+	defVal   int
+	usage    int
+	fn       int
+}
+
+// The value types of the flag library, the function names are derived from them, such as String, StringVar
+var flagTypes = map[string]string{
+	"Bool":     "bool",
+	"Duration": "time.Duration",
+	"Float64":  "float64",
+	"Int":      "int",
+	"Int64":    "int64",
+	"String":   "string",
+	"Uint":     "uint",
+	"Uint64":   "uint64",
+}
+
+// The value types only pflag has, pflag also has the P functions with the shorthand, such as StringP, StringVarP
+var pflagTypes = map[string]string{
+	"Float32":        "float32",
+	"Int8":           "int8",
+	"Int16":          "int16",
+	"Int32":          "int32",
+	"Uint8":          "uint8",
+	"Uint16":         "uint16",
+	"Uint32":         "uint32",
+	"IP":             "net.IP",
+	"BoolSlice":      "[]bool",
+	"DurationSlice":  "[]time.Duration",
+	"Float32Slice":   "[]float32",
+	"Float64Slice":   "[]float64",
+	"IntSlice":       "[]int",
+	"Int32Slice":     "[]int32",
+	"Int64Slice":     "[]int64",
+	"UintSlice":      "[]uint",
+	"IPSlice":        "[]net.IP",
+	"StringSlice":    "[]string",
+	"StringArray":    "[]string",
+	"StringToString": "map[string]string",
+	"StringToInt":    "map[string]int",
+	"StringToInt64":  "map[string]int64",
+}
+
+// Name of the parsing function of the flag library, white name
+var funcName = map[string]argsNumAndType{}
+
+// Name of the parsing function of the pflag library
+var pflagFuncName = map[string]argsNumAndType{}
+
+func init() {
+	for _, m := range []map[string]string{flagTypes, pflagTypes} {
+		for fn, typeName := range m {
+			sep := ""
+			//pflag splits the value of slices by comma, but the string array is not split
+			if strings.HasSuffix(fn, "Slice") || strings.HasPrefix(fn, "StringTo") {
+				sep = defaultSep
+			}
+
+			// String(name, value, usage) StringVar(&v, name, value, usage)
+			// StringP(name, shorthand, value, usage) StringVarP(&v, name, shorthand, value, usage)
+			value := argsNumAndType{size: 3, typeName: typeName, sep: sep, ptr: -1, name: 0, short: -1, defVal: 1, usage: 2, fn: -1}
+			valueVar := argsNumAndType{size: 4, typeName: typeName, sep: sep, ptr: 0, name: 1, short: -1, defVal: 2, usage: 3, fn: -1}
+			valueP := argsNumAndType{size: 4, typeName: typeName, sep: sep, ptr: -1, name: 0, short: 1, defVal: 2, usage: 3, fn: -1}
+			valueVarP := argsNumAndType{size: 5, typeName: typeName, sep: sep, ptr: 0, name: 1, short: 2, defVal: 3, usage: 4, fn: -1}
+
+			if _, ok := flagTypes[fn]; ok {
+				funcName[fn] = value
+				funcName[fn+"Var"] = valueVar
+			}
+			pflagFuncName[fn] = value
+			pflagFuncName[fn+"Var"] = valueVar
+			pflagFuncName[fn+"P"] = valueP
+			pflagFuncName[fn+"VarP"] = valueVarP
+		}
+	}
+
+	// Func(name, usage, fn) Var(&v, name, usage) TextVar(&v, name, value, usage)
+	// The type of Var and TextVar is the type of the variable
+	funcName["Func"] = argsNumAndType{size: 3, typeName: "string", ptr: -1, name: 0, short: -1, defVal: -1, usage: 1, fn: 2}
+	funcName["BoolFunc"] = argsNumAndType{size: 3, typeName: "bool", ptr: -1, name: 0, short: -1, defVal: -1, usage: 1, fn: 2}
+	funcName["Var"] = argsNumAndType{size: 3, ptr: 0, name: 1, short: -1, defVal: -1, usage: 2, fn: -1}
+	funcName["TextVar"] = argsNumAndType{size: 4, ptr: 0, name: 1, short: -1, defVal: 2, usage: 3, fn: -1}
+	for _, fn := range []string{"Func", "BoolFunc", "Var"} {
+		pflagFuncName[fn] = funcName[fn]
+	}
+	// VarP(&v, name, shorthand, usage)
+	pflagFuncName["VarP"] = argsNumAndType{size: 4, ptr: 0, name: 1, short: 2, defVal: -1, usage: 3, fn: -1}
 }
 
-var funcName = map[string]argsNumAndType{
-	//"Func":true, TODO
-	"Bool":        {3, "bool"},
-	"BoolVar":     {4, "bool"},
-	"Duration":    {3, "time.Duration"},
-	"DurationVar": {4, "time.Duration"},
-	"Float64":     {3, "float64"},
-	"Float64Var":  {4, "float64"},
-	"Int":         {3, "int"},
-	"IntVar":      {4, "int"},
-	"Int64":       {3, "int64"},
-	"Int64Var":    {4, "int64"},
-	"String":      {3, "string"},
-	"StringVar":   {4, "string"},
-	"Uint":        {3, "uint"},
-	"UintVar":     {4, "uint"},
-	"Uint64":      {3, "uint64"},
-	"Uint64Var":   {4, "uint64"},
+// The import path of the flag libraries, the value indicates pflag
+var flagImportPath = map[string]bool{
+	"flag":                    false,
+	"github.com/spf13/pflag":  true,
+	"github.com/ogier/pflag":  true,
+	"gopkg.in/spf13/pflag.v1": true,
 }
 
 // Parse flag
 type ParseFlag struct {
 	astFile        *ast.File
 	fileName       string
+	src            interface{}
 	funcAndArgs    map[string]funcAndArgs
+	imports        map[string]bool
+	assigned       map[*ast.CallExpr]string
 	haveStruct     bool
 	haveImportPath bool
 	haveMain       bool
+
+	//The import names of cobra and urfave/cli, and the commands found
+	cobra       string
+	cli         string
+	commands    []*commandDef
+	commandVars map[*ast.Object]*commandDef
+	commandLits map[*ast.CompositeLit]*commandDef
+	//The variables of cobra's PersistentFlags(), such as flags := cmd.PersistentFlags()
+	persistentSets map[*ast.Object]bool
+
+	//The *flag.FlagSet parameters of the functions, and the calls that pass a flag set to them
+	params     []*paramSet
+	paramObjs  map[*ast.Object]*paramSet
+	funcParams map[*ast.Object][]*paramSet
+	paramCalls []paramCall
+}
+
+// A *flag.FlagSet parameter of a function, its flags go to the flag sets passed to the function
+type paramSet struct {
+	fn    string
+	name  string
+	pflag bool
+	args  []flagOpt
+	used  bool
+}
+
+// A call passing the flag set to the parameter, pos is the number of the flags of the set before the call
+type paramCall struct {
+	param *paramSet
+	set   string
+	pos   int
 }
 
 // Constructor
@@ -69,28 +187,168 @@ func (p *ParseFlag) FromFile(fileName string) *ParseFlag {
 	return p
 }
 
+// Parse the source code instead of reading the file, src is a string, []byte or io.Reader
+func (p *ParseFlag) FromSource(fileName string, src interface{}) *ParseFlag {
+	p.fileName = fileName
+	p.src = src
+	return p
+}
+
 func (p *ParseFlag) Parse() ([]byte, error) {
 	p.funcAndArgs = make(map[string]funcAndArgs)
+	p.imports = make(map[string]bool)
+	p.assigned = make(map[*ast.CallExpr]string)
+	p.params = nil
+	p.paramObjs = make(map[*ast.Object]*paramSet)
+	p.funcParams = make(map[*ast.Object][]*paramSet)
+	p.paramCalls = nil
 	if err := p.getFuncCallsToken(); err != nil {
 		return nil, err
 	}
 
-	return genStructBytes(p)
+	code, err := genStructBytes(p)
+	if err != nil {
+		return nil, err
+	}
+
+	commands, err := genCommandBytes(p)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(code, commands...), p.unsupportedParams()...), nil
+}
+
+// Record the *flag.FlagSet parameters of the function, nil for the other parameters
+func (p *ParseFlag) findParams(fn string, typ *ast.FuncType) []*paramSet {
+	var params []*paramSet
+	for _, field := range typ.Params.List {
+		var set *paramSet
+		if star, ok := field.Type.(*ast.StarExpr); ok {
+			for pkg, isPflag := range p.imports {
+				if isFunc(star.X, pkg, "FlagSet") {
+					set = &paramSet{fn: fn, pflag: isPflag}
+				}
+			}
+		}
+
+		//The parameters without a name are still counted
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{nil}
+		}
+		for _, n := range names {
+			if set == nil || n == nil || n.Obj == nil {
+				params = append(params, nil)
+				continue
+			}
+
+			s := *set
+			s.name = n.Name
+			p.params = append(p.params, &s)
+			p.paramObjs[n.Obj] = &s
+			params = append(params, &s)
+		}
+	}
+	return params
+}
+
+// Record the flag sets passed to the *flag.FlagSet parameters, such as register(serve) or register(flag.CommandLine)
+func (p *ParseFlag) findParamCall(call *ast.CallExpr) {
+	params := p.funcParams[objectOf(call.Fun)]
+	for i, arg := range call.Args {
+		if i >= len(params) || params[i] == nil {
+			continue
+		}
+
+		set := ""
+		if _, ok := p.paramObjs[objectOf(arg)]; !ok {
+			if _, ok := p.funcAndArgs[getIdentName(arg)]; ok {
+				set = getIdentName(arg)
+			}
+		}
+		for pkg := range p.imports {
+			if isFunc(arg, pkg, "CommandLine") {
+				set = pkg
+			}
+		}
+
+		if len(set) > 0 {
+			p.paramCalls = append(p.paramCalls, paramCall{param: params[i], set: set, pos: len(p.funcAndArgs[set].args)})
+		}
+	}
+}
+
+// Insert the flags of the parameters where the functions are called, the later calls first to keep the positions
+func (p *ParseFlag) resolveParamCalls() {
+	for i := len(p.paramCalls) - 1; i >= 0; i-- {
+		c := p.paramCalls[i]
+		set := p.funcAndArgs[c.set]
+		args := append([]flagOpt{}, set.args[:c.pos]...)
+		args = append(args, c.param.args...)
+		set.args = append(args, set.args[c.pos:]...)
+		p.funcAndArgs[c.set] = set
+		c.param.used = true
+	}
+}
+
+// The flags of the parameters that are never given a flag set of the file can not be migrated, they are listed as comments
+func (p *ParseFlag) unsupportedParams() []byte {
+	var code bytes.Buffer
+	for _, param := range p.params {
+		if param.used || len(param.args) == 0 {
+			continue
+		}
+
+		names := make([]string, 0, len(param.args))
+		for _, arg := range param.args {
+			if len(arg.optName) == 1 {
+				names = append(names, "-"+arg.optName)
+			} else if len(arg.optName) > 0 {
+				names = append(names, "--"+arg.optName)
+			}
+		}
+		fmt.Fprintf(&code, "// unsupported: the flags of %s, the *FlagSet parameter of %s, are not migrated: %s\n",
+			param.name, param.fn, strings.Join(names, ", "))
+	}
+	return code.Bytes()
+}
+
+// The names of the flag packages and flag sets, in a stable order
+func (p *ParseFlag) sortedNames() []string {
+	names := make([]string, 0, len(p.funcAndArgs))
+	for k := range p.funcAndArgs {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
 }
 
 // The address called by each flag library is resolved into the funcAndArgs structure
 type funcAndArgs struct {
 	args          []flagOpt
 	haveParseFunc bool
+	pflag         bool
 }
 
 // Save the metadata extracted from the ast
 type flagOpt struct {
 	varName  string
 	optName  string
+	short    string
 	defVal   string
 	usage    string
 	typeName string
+	sep      string
+	funcName string
+	long     []string
+	env      string
+	required bool
+	//The flag of cobra's PersistentFlags(), the subcommands of the generated code do not inherit it
+	persistent bool
+	//The default that can not be written in the default tag, such as 3*time.Second
+	defExpr string
+	//The parts of the flag that can not be migrated, they are written as a comment
+	notes []string
 }
 
 // It can be determined that it is the function you want, such as flag. String
@@ -99,14 +357,78 @@ func isFunc(expr ast.Expr, pkg, fn string) bool {
 	return ok && isIdent(f.X, pkg) && isIdent(f.Sel, fn)
 }
 
+// Get the name of the variable, &port or &cfg.port
 func getPtrArgName(arg ast.Expr) string {
 	a, ok := arg.(*ast.UnaryExpr)
 	if !ok {
-		return ""
+		return getIdentName(arg)
+	}
+
+	if s, ok := a.X.(*ast.SelectorExpr); ok {
+		return s.Sel.Name
 	}
 	return getIdentName(a.X)
 }
 
+// Get the type name of the variable that the pointer argument points to
+func getPtrArgType(arg ast.Expr) string {
+	var ident *ast.Ident
+	deref := false
+	switch a := arg.(type) {
+	case *ast.UnaryExpr:
+		ident, _ = a.X.(*ast.Ident)
+	case *ast.Ident:
+		ident, deref = a, true
+	}
+
+	if ident == nil || ident.Obj == nil {
+		return ""
+	}
+
+	var typ ast.Expr
+	switch d := ident.Obj.Decl.(type) {
+	case *ast.ValueSpec:
+		typ = d.Type
+		for i, n := range d.Names {
+			if typ == nil && n.Name == ident.Name && i < len(d.Values) {
+				typ = typeOfValue(d.Values[i])
+			}
+		}
+	case *ast.AssignStmt:
+		for i, l := range d.Lhs {
+			if isIdent(l, ident.Name) && i < len(d.Rhs) {
+				typ = typeOfValue(d.Rhs[i])
+			}
+		}
+	}
+
+	if typ == nil {
+		return ""
+	}
+
+	if s, ok := typ.(*ast.StarExpr); ok && deref {
+		typ = s.X
+	}
+	return types.ExprString(typ)
+}
+
+// The type of the value, only T{}, &T{} and new(T) are known
+func typeOfValue(v ast.Expr) ast.Expr {
+	switch v := v.(type) {
+	case *ast.CompositeLit:
+		return v.Type
+	case *ast.UnaryExpr:
+		if t := typeOfValue(v.X); v.Op == token.AND && t != nil {
+			return &ast.StarExpr{X: t}
+		}
+	case *ast.CallExpr:
+		if isIdent(v.Fun, "new") && len(v.Args) == 1 {
+			return &ast.StarExpr{X: v.Args[0]}
+		}
+	}
+	return nil
+}
+
 // Get Function Name
 func getArgName(arg ast.Expr) string {
 	a, ok := arg.(*ast.BasicLit)
@@ -116,9 +438,101 @@ func getArgName(arg ast.Expr) string {
 	return a.Value
 }
 
+// Get the default value from the literal, the other expressions can not be evaluated.
+// The slices and maps are written in JSON
+func getDefValue(arg ast.Expr) string {
+	switch a := arg.(type) {
+	case *ast.BasicLit:
+		if v, err := strconv.Unquote(a.Value); err == nil {
+			return v
+		}
+		return a.Value
+	case *ast.Ident:
+		if a.Name == "true" || a.Name == "false" {
+			return a.Name
+		}
+	case *ast.UnaryExpr:
+		if v := getDefValue(a.X); a.Op == token.SUB && len(v) > 0 {
+			return "-" + v
+		}
+	case *ast.CompositeLit:
+		return getJSONValue(a)
+	}
+	return ""
+}
+
+// Set the default converted from e, the default that can not be converted is kept to be noted in the comment
+func (opt *flagOpt) setDefValue(e ast.Expr, def string) {
+	opt.defVal = def
+	if len(def) == 0 && !isEmptyDefValue(e) {
+		opt.defExpr = types.ExprString(e)
+	}
+}
+
+// The default is empty, such as "" or []string{}
+func isEmptyDefValue(e ast.Expr) bool {
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		return true
+	case *ast.CompositeLit:
+		return len(e.Elts) == 0
+	case *ast.Ident:
+		return e.Name == "nil"
+	case *ast.CallExpr:
+		return isCliSlice(e) && len(e.Args) == 0
+	}
+	return false
+}
+
+func getJSONValue(arg ast.Expr) string {
+	switch a := arg.(type) {
+	case *ast.BasicLit:
+		if a.Kind == token.STRING {
+			if v, err := strconv.Unquote(a.Value); err == nil {
+				return strconv.Quote(v)
+			}
+		}
+		return a.Value
+	case *ast.Ident:
+		if a.Name == "true" || a.Name == "false" {
+			return a.Name
+		}
+	case *ast.UnaryExpr:
+		if v := getJSONValue(a.X); a.Op == token.SUB && len(v) > 0 {
+			return "-" + v
+		}
+	case *ast.CompositeLit:
+		if len(a.Elts) == 0 {
+			return ""
+		}
+
+		open, end := "[", "]"
+		elts := make([]string, len(a.Elts))
+		for i, e := range a.Elts {
+			if kv, ok := e.(*ast.KeyValueExpr); ok {
+				open, end = "{", "}"
+				key := getJSONValue(kv.Key)
+				if !strings.HasPrefix(key, `"`) {
+					key = strconv.Quote(key)
+				}
+				e = kv.Value
+				elts[i] = key + ":"
+			}
+
+			v := getJSONValue(e)
+			if len(v) == 0 {
+				return ""
+			}
+			elts[i] += v
+		}
+		return open + strings.Join(elts, ",") + end
+	}
+	return ""
+}
+
 // Extract function name and formal parameter
-func (p *ParseFlag) takeFuncNameAndArgs(expr ast.Expr, args []ast.Expr) (err error) {
-	f, ok := expr.(*ast.SelectorExpr)
+func (p *ParseFlag) takeFuncNameAndArgs(call *ast.CallExpr) (err error) {
+	f, ok := call.Fun.(*ast.SelectorExpr)
 	if !ok {
 		return nil
 	}
@@ -126,66 +540,97 @@ func (p *ParseFlag) takeFuncNameAndArgs(expr ast.Expr, args []ast.Expr) (err err
 	obj := getIdentName(f.X)
 	fn := getIdentName(f.Sel)
 
-	argsNumType, ok := funcName[fn]
-	if !ok {
-		if fn == "Parse" {
-			if v, ok := p.funcAndArgs[obj]; ok {
-				v.haveParseFunc = true
-				p.funcAndArgs[obj] = v
+	//The flags of a *flag.FlagSet parameter go to the flag sets passed to the function
+	if param, ok := p.paramObjs[objectOf(f.X)]; ok {
+		argsNumType, ok := funcName[fn]
+		if param.pflag {
+			argsNumType, ok = pflagFuncName[fn]
+		}
+		if ok && argsNumType.size == len(call.Args) {
+			opt := newFlagOpt(call, argsNumType)
+			if argsNumType.ptr < 0 {
+				opt.varName = p.assigned[call]
 			}
+			param.args = append(param.args, opt)
 		}
 		return nil
 	}
 
-	if _, ok := p.funcAndArgs[obj]; !ok {
-		p.funcAndArgs[obj] = funcAndArgs{}
+	set, ok := p.funcAndArgs[obj]
+	if !ok {
+		return nil
 	}
 
-	if argsNumType.size != len(args) {
+	if fn == "Parse" {
+		set.haveParseFunc = true
+		p.funcAndArgs[obj] = set
 		return nil
 	}
 
-	var opt flagOpt
-	strArgs := make([]string, len(args))
-	for i := range args {
-		arg := ""
-		arg2 := ""
-		if len(strArgs) == 4 && i == 0 {
-			arg = getPtrArgName(args[i])
-			goto next
-		}
+	argsNumType, ok := funcName[fn]
+	if set.pflag {
+		argsNumType, ok = pflagFuncName[fn]
+	}
 
-		arg = getArgName(args[i])
+	if !ok || argsNumType.size != len(call.Args) {
+		return nil
+	}
+
+	opt := newFlagOpt(call, argsNumType)
+	if argsNumType.ptr < 0 {
+		//The variable that the pointer is assigned to, such as port := flag.Int(...)
+		opt.varName = p.assigned[call]
+	}
+
+	set.args = append(set.args, opt)
+	p.funcAndArgs[obj] = set
 
-	next:
-		arg2, err = strconv.Unquote(arg)
+	return nil
+}
+
+// Extract the option from the arguments of the flag function
+func newFlagOpt(call *ast.CallExpr, argsNumType argsNumAndType) flagOpt {
+	args := call.Args
+	str := func(i int) string {
+		if i < 0 {
+			return ""
+		}
+
+		arg := getArgName(args[i])
+		arg2, err := strconv.Unquote(arg)
 		if err != nil {
 			arg2 = arg
-			//return err
 		}
+		return arg2
+	}
 
-		strArgs[i] = arg2
+	opt := flagOpt{
+		optName:  str(argsNumType.name),
+		short:    str(argsNumType.short),
+		usage:    str(argsNumType.usage),
+		typeName: argsNumType.typeName,
+		sep:      argsNumType.sep,
 	}
 
-	if argsNumType.size == 3 {
-		opt.varName = obj
-		opt.optName = strArgs[0]
-		opt.defVal = strArgs[1]
-		opt.usage = strArgs[2]
+	if argsNumType.defVal >= 0 {
+		opt.setDefValue(args[argsNumType.defVal], getDefValue(args[argsNumType.defVal]))
+	}
 
-	} else {
-		opt.varName = strArgs[0]
-		opt.optName = strArgs[1]
-		opt.defVal = strArgs[2]
-		opt.usage = strArgs[3]
+	if argsNumType.fn >= 0 {
+		opt.funcName = types.ExprString(args[argsNumType.fn])
+		if _, ok := args[argsNumType.fn].(*ast.FuncLit); ok {
+			opt.funcName = "func literal"
+		}
 	}
 
-	opt.typeName = argsNumType.typeName
-	oldVal := p.funcAndArgs[obj]
-	oldVal.args = append(oldVal.args, opt)
-	p.funcAndArgs[obj] = oldVal
+	if argsNumType.ptr >= 0 {
+		opt.varName = getPtrArgName(args[argsNumType.ptr])
+		if len(opt.typeName) == 0 {
+			opt.typeName = getPtrArgType(args[argsNumType.ptr])
+		}
+	}
 
-	return nil
+	return opt
 }
 
 func isIdent(expr ast.Expr, name string) bool {
@@ -202,26 +647,51 @@ func getIdentName(expr ast.Expr) string {
 	return ""
 }
 
-// Parsing Flag.NewFlagSet Code
-func (p *ParseFlag) parserFlagNewFlagSet(stmt *ast.AssignStmt) {
-	if (stmt.Tok == token.ASSIGN || stmt.Tok == token.DEFINE) && len(stmt.Rhs) > 0 {
-		if isFunc(stmt.Rhs[0], "flag", "NewFlagSet") && len(stmt.Lhs) > 0 {
-			name := getIdentName(stmt.Lhs[0])
-			if len(name) == 0 {
-				return
-			}
+// Parsing Flag.NewFlagSet Code, and save the variables the calls are assigned to
+func (p *ParseFlag) parserFlagNewFlagSet(lhs []ast.Expr, rhs []ast.Expr) {
+	if len(lhs) != len(rhs) {
+		return
+	}
 
-			p.funcAndArgs[name] = funcAndArgs{}
+	for i := range rhs {
+		call, ok := rhs[i].(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+
+		name := getIdentName(lhs[i])
+		if len(name) == 0 || name == "_" {
+			continue
+		}
+		p.assigned[call] = name
 
+		for pkg, isPflag := range p.imports {
+			if isFunc(call.Fun, pkg, "NewFlagSet") {
+				p.funcAndArgs[name] = funcAndArgs{pflag: isPflag}
+			}
 		}
 	}
 }
 
 // Code for parsing function calls
 func (p *ParseFlag) findFuncCalls(node ast.Node) bool {
-	stmt, ok := node.(*ast.AssignStmt)
-	if ok {
-		p.parserFlagNewFlagSet(stmt)
+	switch stmt := node.(type) {
+	case *ast.AssignStmt:
+		if stmt.Tok == token.ASSIGN || stmt.Tok == token.DEFINE {
+			p.parserFlagNewFlagSet(stmt.Lhs, stmt.Rhs)
+		}
+		return true
+	case *ast.ValueSpec:
+		lhs := make([]ast.Expr, len(stmt.Names))
+		for i, n := range stmt.Names {
+			lhs[i] = n
+		}
+		p.parserFlagNewFlagSet(lhs, stmt.Values)
+		return true
+	}
+
+	if lit, ok := node.(*ast.FuncLit); ok {
+		p.findParams("func literal", lit.Type)
 		return true
 	}
 
@@ -230,7 +700,8 @@ func (p *ParseFlag) findFuncCalls(node ast.Node) bool {
 		return true
 	}
 
-	err := p.takeFuncNameAndArgs(call.Fun, call.Args)
+	p.findParamCall(call)
+	err := p.takeFuncNameAndArgs(call)
 	if err != nil {
 		// debug
 		//panic(err.Error())
@@ -239,19 +710,57 @@ func (p *ParseFlag) findFuncCalls(node ast.Node) bool {
 	return true
 }
 
+// Find the names of the flag packages
+func (p *ParseFlag) findImports() {
+	for _, imp := range p.astFile.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		isPflag, ok := flagImportPath[path]
+		if !ok {
+			continue
+		}
+
+		name := path[strings.LastIndex(path, "/")+1:]
+		if isPflag {
+			name = "pflag"
+		}
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+
+		p.imports[name] = isPflag
+		p.funcAndArgs[name] = funcAndArgs{pflag: isPflag}
+	}
+}
+
 // Get functions and formal parameters
 func (p *ParseFlag) getFuncCallsToken() (err error) {
 
 	fset := token.NewFileSet()
 
-	f, err := parser.ParseFile(fset, p.fileName, nil, 0)
+	f, err := parser.ParseFile(fset, p.fileName, p.src, 0)
 	if err != nil {
 		return err
 	}
 
-	p.funcAndArgs["flag"] = funcAndArgs{}
 	p.astFile = f
+	p.findImports()
+
+	//The functions can be declared after they are called
+	for _, decl := range f.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Name.Obj != nil {
+			p.funcParams[fn.Name.Obj] = p.findParams(fn.Name.Name, fn.Type)
+		} else if ok && fn.Recv != nil {
+			//The methods are not resolved, their parameters are reported
+			p.findParams(fn.Name.Name, fn.Type)
+		}
+	}
 
 	ast.Inspect(p.astFile, p.findFuncCalls)
+	p.resolveParamCalls()
+	p.findCommands()
 	return nil
 }
@@ -0,0 +1,246 @@
+package screw
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// PluginExit is returned by Bind when a plugin subcommand ran and the process does not exit
+type PluginExit struct {
+	Name string
+	Path string
+	Code int
+}
+
+func (e *PluginExit) Error() string {
+	return fmt.Sprintf("error: the plugin %s exited with %d", e.Name, e.Code)
+}
+
+// Enable the plugin subcommands. An unknown subcommand name of a command that has no args
+// runs <procName>-<name> found in PATH with the remaining arguments, such as tool-foo for "tool foo".
+// The options given before the name are forwarded as env. It must be called before the structures are registered
+func (c *Screw) SetPlugins(enable bool) *Screw {
+	c.plugins = enable
+	return c
+}
+
+// Search the plugins in the dirs instead of PATH, it enables the plugins
+func (c *Screw) SetPluginDirs(dirs ...string) *Screw {
+	c.plugins = true
+	c.pluginDirs = dirs
+	return c
+}
+
+// The prefix of the plugins of the command, such as tool- or tool-sub-.
+// The root is named by the base of its procName, the package level Bind sets the path of os.Args[0]
+func (c *command) pluginPrefix() string {
+	names := []string{}
+	for p := c; p != nil; p = p.parent {
+		name := p.procName
+		if p.parent == nil {
+			if len(name) == 0 {
+				name = os.Args[0]
+			}
+			name = filepath.Base(name)
+		}
+		names = append([]string{name}, names...)
+	}
+	return strings.Join(names, "-") + "-"
+}
+
+func (c *command) pluginSearchDirs(lookupEnv func(string) (string, bool)) []string {
+	root := c.getRoot()
+	if len(root.pluginDirs) > 0 {
+		return root.pluginDirs
+	}
+
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+	path, _ := lookupEnv("PATH")
+	return filepath.SplitList(path)
+}
+
+func isExecutable(fi os.FileInfo) bool {
+	if fi.IsDir() {
+		return false
+	}
+	return runtime.GOOS == "windows" || fi.Mode()&0111 != 0
+}
+
+// The plugin of the name, the first one found in the dirs wins.
+// Only the file of the name is looked up in each dir, the dirs are listed for the help only
+func (c *command) findPlugin(name string, lookupEnv func(string) (string, bool)) (string, bool) {
+	if len(name) == 0 || strings.ContainsAny(name, `/\`) {
+		return "", false
+	}
+	if _, ok := c.subcommand[name]; ok {
+		return "", false
+	}
+
+	file := c.pluginPrefix() + name
+	for _, dir := range c.pluginSearchDirs(lookupEnv) {
+		if len(dir) == 0 {
+			continue
+		}
+
+		//LookPath checks the file itself when the name has a separator, and tries PATHEXT on windows
+		path := filepath.Join(dir, file)
+		if !strings.ContainsRune(path, filepath.Separator) {
+			path = "." + string(filepath.Separator) + path
+		}
+		if path, err := exec.LookPath(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// The plugins of the command by name for the help, the first one found in the dirs wins
+func (c *command) findPlugins(lookupEnv func(string) (string, bool)) map[string]string {
+	plugins := make(map[string]string)
+	prefix := c.pluginPrefix()
+	for _, dir := range c.pluginSearchDirs(lookupEnv) {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, fi := range files {
+			name := fi.Name()
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+
+			if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) || !isExecutable(fi) {
+				continue
+			}
+
+			name = name[len(prefix):]
+			if _, ok := c.subcommand[name]; ok {
+				continue
+			}
+			if _, ok := plugins[name]; !ok {
+				plugins[name] = filepath.Join(dir, fi.Name())
+			}
+		}
+	}
+	return plugins
+}
+
+// Run the plugin with the remaining arguments, the options set by the command line are forwarded as env
+func (c *cmdParser) runPlugin(name string, path string, args []string) error {
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = c.w
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(c.pluginEnviron(), c.pluginEnv()...)
+
+	code := 0
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return err
+		}
+		code = exitErr.ExitCode()
+	}
+
+	if c.exit {
+		os.Exit(code)
+	}
+	return &PluginExit{Name: name, Path: path, Code: code}
+}
+
+// The env of the plugin is read from the source of SetEnviron or SetEnv, the process env by default.
+// A lookup function can not be listed, so it is asked for the names of the process env.
+// A nil env of exec.Cmd is the process env, so it is never nil
+func (c *cmdParser) pluginEnviron() []string {
+	switch {
+	case c.environ != nil:
+		return append([]string{}, c.environ...)
+	case c.lookupEnv != nil:
+		env := []string{}
+		for _, kv := range os.Environ() {
+			pos := strings.IndexByte(kv, '=')
+			if pos <= 0 {
+				continue
+			}
+			if val, ok := c.lookupEnv(kv[:pos]); ok {
+				env = append(env, kv[:pos]+"="+val)
+			}
+		}
+		return env
+	}
+	return os.Environ()
+}
+
+// The options of the command and its parents set before the plugin name, as NAME=value
+func (c *cmdParser) pluginEnv() []string {
+	var env []string
+	root := c.getRoot()
+	for p := c.command; p != nil; p = p.parent {
+		for _, o := range p.options() {
+			s := c.states[o.id]
+			if s.Option == nil || s.source.Kind != SourceFlag {
+				continue
+			}
+
+			v, ok := c.lookupValue(o.field)
+			if !ok {
+				continue
+			}
+			values, err := o.marshalValues(v)
+			if err != nil {
+				continue
+			}
+
+			//Such as TOOL_VERBOSE for --verbose
+			name := o.envName
+			if len(name) == 0 {
+				name, _ = envOptionName(root.pluginPrefix() + strings.TrimLeft(o.showName(), "-"))
+			}
+			env = append(env, name+"="+strings.Join(values, o.envSep()))
+		}
+	}
+
+	sort.Strings(env)
+	return env
+}
+
+// The plugins are listed with the subcommands
+func (c *command) pluginHelp(h *Help, lookupEnv func(string) (string, bool)) {
+	//The words of a command with args are positional arguments, the plugins can not run there
+	if !c.getRoot().plugins || len(c.checkArgs) > 0 {
+		return
+	}
+
+	plugins := c.findPlugins(lookupEnv)
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := plugins[name]
+		if h.MaxNameLen < len(name) {
+			h.MaxNameLen = len(name)
+		}
+		h.Subcommand = append(h.Subcommand, showOption{Opt: name, Usage: "plugin " + path})
+	}
+}
+
+func SetPlugins(enable bool) {
+	CommandLine.SetPlugins(enable)
+}
+
+func SetPluginDirs(dirs ...string) {
+	CommandLine.SetPluginDirs(dirs...)
+}
@@ -0,0 +1,189 @@
+package screw_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/RainFallsSilent/screw"
+	"github.com/RainFallsSilent/screw/screwtest"
+)
+
+type pluginServe struct {
+	Port int `screw:"-p;--port" usage:"port"`
+}
+
+type pluginConfig struct {
+	Verbose bool        `screw:"-v;--verbose" usage:"verbose"`
+	Serve   pluginServe `screw:"subcommand=serve" usage:"serve"`
+}
+
+type pluginRoot struct {
+	Verbose bool `screw:"-v;--verbose" usage:"verbose"`
+}
+
+type pluginArgs struct {
+	Verbose bool     `screw:"-v;--verbose" usage:"verbose"`
+	Files   []string `screw:"args=files" usage:"files"`
+}
+
+// The plugins print their arguments and env, app-fail exits with its first argument
+func pluginDir(t *testing.T) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("the plugins are shell scripts")
+	}
+
+	dir := t.TempDir()
+	for name, script := range map[string]string{
+		"app-foo":   "echo \"args:$*\"\necho \"env:$APP_VERBOSE:$GREETING:$SCREW_LEAK\"\n",
+		"app-fail":  "exit $1\n",
+		"app-serve": "echo plugin serve\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	//Not executable
+	if err := ioutil.WriteFile(filepath.Join(dir, "app-data"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func Test_Plugin(t *testing.T) {
+	dir := pluginDir(t)
+	setup := func(s *screw.Screw) { s.SetProcName("app").SetPluginDirs(dir) }
+
+	os.Setenv("GREETING", "process")
+	defer os.Unsetenv("GREETING")
+
+	for _, test := range []struct {
+		h    *screwtest.Harness
+		need string
+	}{
+		{
+			h:    screwtest.New("-v", "foo", "a", "--b").Env(map[string]string{"GREETING": "hi"}).Setup(setup),
+			need: "args:a --b\nenv:true:hi:\n",
+		},
+		{
+			//Only the names of the process env are looked up in the SetEnv function
+			h:    screwtest.New("foo").Env(map[string]string{"SCREW_LEAK": "x"}).Setup(setup),
+			need: "args:\nenv:::\n",
+		},
+	} {
+		r := test.h.Run(new(pluginConfig))
+		var exit *screw.PluginExit
+		if !errors.As(r.Err, &exit) || exit.Name != "foo" || exit.Code != 0 || exit.Path != filepath.Join(dir, "app-foo") {
+			t.Fatalf("got %v", r.Err)
+		}
+		if r.Output != test.need {
+			t.Errorf("got %q, need %q", r.Output, test.need)
+		}
+	}
+}
+
+// The root is named by the base of the procName, such as the path of os.Args[0]
+func Test_Plugin_ProcPath(t *testing.T) {
+	dir := pluginDir(t)
+	var out strings.Builder
+	err := screw.New([]string{"-v", "foo", "a"}).SetExit(false).SetOutput(&out).SetProcName(filepath.Join(dir, "bin", "app")).
+		SetPluginDirs(dir).Bind(new(pluginConfig))
+	var exit *screw.PluginExit
+	if !errors.As(err, &exit) || exit.Path != filepath.Join(dir, "app-foo") {
+		t.Fatalf("got %v", err)
+	}
+	if out.String() != "args:a\nenv:true::\n" {
+		t.Errorf("got %q", out.String())
+	}
+}
+
+// The env of the plugin is the SetEnviron list, the process env is not leaked
+func Test_Plugin_Environ(t *testing.T) {
+	dir := pluginDir(t)
+	os.Setenv("SCREW_LEAK", "x")
+	defer os.Unsetenv("SCREW_LEAK")
+
+	var out strings.Builder
+	err := screw.New([]string{"--verbose", "foo"}).SetExit(false).SetOutput(&out).SetProcName("app").
+		SetPluginDirs(dir).SetEnviron([]string{"GREETING=hi"}).Bind(new(pluginConfig))
+	if _, ok := err.(*screw.PluginExit); !ok {
+		t.Fatalf("got %v", err)
+	}
+	if out.String() != "args:\nenv:true:hi:\n" {
+		t.Errorf("got %q", out.String())
+	}
+}
+
+// A command without subcommands runs the plugins it lists in the help
+func Test_Plugin_NoSubcommand(t *testing.T) {
+	dir := pluginDir(t)
+	setup := func(s *screw.Screw) { s.SetProcName("app").SetPluginDirs(dir) }
+
+	r := screwtest.New("-v", "foo", "x").Setup(setup).Run(new(pluginRoot))
+	var exit *screw.PluginExit
+	if !errors.As(r.Err, &exit) || exit.Name != "foo" || r.Output != "args:x\nenv:true::\n" {
+		t.Fatalf("got %v %q", r.Err, r.Output)
+	}
+
+	r = screwtest.New().Setup(setup).Help(new(pluginRoot)).NoError(t)
+	if !strings.Contains(r.Output, "plugin "+filepath.Join(dir, "app-foo")) {
+		t.Errorf("foo is not in the help:\n%s", r.Output)
+	}
+}
+
+func Test_Plugin_Exit(t *testing.T) {
+	dir := pluginDir(t)
+	r := screwtest.New("fail", "3").Setup(func(s *screw.Screw) { s.SetProcName("app").SetPluginDirs(dir) }).Run(new(pluginConfig))
+	var exit *screw.PluginExit
+	if !errors.As(r.Err, &exit) || exit.Code != 3 || exit.Error() != "error: the plugin fail exited with 3" {
+		t.Errorf("got %v", r.Err)
+	}
+}
+
+// The words that are not unknown subcommands never run a plugin
+func Test_Plugin_NotRun(t *testing.T) {
+	dir := pluginDir(t)
+	setup := func(s *screw.Screw) { s.SetProcName("app").SetPluginDirs(dir) }
+
+	//The registered subcommand wins
+	var c pluginConfig
+	r := screwtest.New("serve", "-p", "81").Setup(setup).Run(&c).NoError(t)
+	if c.Serve.Port != 81 || len(r.Output) > 0 {
+		t.Errorf("got %+v %q", c, r.Output)
+	}
+
+	//The word is a positional argument
+	var a pluginArgs
+	screwtest.New("foo", "a").Setup(setup).Run(&a).NoError(t)
+	if strings.Join(a.Files, " ") != "foo a" {
+		t.Errorf("got %q", a.Files)
+	}
+
+	//The plugins are not listed where the words are positional arguments
+	r = screwtest.New().Setup(setup).Help(new(pluginArgs)).NoError(t)
+	if strings.Contains(r.Output, "plugin") {
+		t.Errorf("got the help:\n%s", r.Output)
+	}
+
+	for _, name := range []string{"data", "bar", "../app-foo", "/app-foo"} {
+		screwtest.New(name).Setup(setup).Run(new(pluginConfig)).ErrorContains(t, "Unknown subcommand:"+name)
+	}
+	screwtest.New("foo").Setup(func(s *screw.Screw) { s.SetProcName("app") }).Run(new(pluginConfig)).ErrorContains(t, "Unknown subcommand:foo")
+}
+
+func Test_Plugin_Help(t *testing.T) {
+	dir := pluginDir(t)
+	r := screwtest.New().Setup(func(s *screw.Screw) { s.SetProcName("app").SetPluginDirs(dir) }).Help(new(pluginConfig)).NoError(t)
+	for _, need := range []string{"fail", "foo", "plugin " + filepath.Join(dir, "app-foo")} {
+		if !strings.Contains(r.Output, need) {
+			t.Errorf("%q is not in the help:\n%s", need, r.Output)
+		}
+	}
+	if strings.Contains(r.Output, "data") || strings.Contains(r.Output, "app-serve") {
+		t.Errorf("got the help:\n%s", r.Output)
+	}
+}
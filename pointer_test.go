@@ -0,0 +1,93 @@
+package screw
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+type pointerTLS struct {
+	Cert string `screw:"--cert" usage:"cert"`
+}
+
+type pointerConfig struct {
+	Port  *int        `screw:"-p;--port" usage:"port"`
+	Debug *bool       `screw:"-d" usage:"debug"`
+	Level **int       `screw:"--level;env=LEVEL" usage:"level"`
+	Name  *string     `screw:"--name" default:"app" usage:"name"`
+	TLS   *pointerTLS `usage:"tls"`
+	Files *[]string   `screw:"args=files" usage:"files"`
+}
+
+func Test_Pointer(t *testing.T) {
+	for _, test := range []struct {
+		args  []string
+		env   []string
+		check func(c *pointerConfig) bool
+	}{
+		{
+			//Only the default allocates the pointer
+			args: nil,
+			check: func(c *pointerConfig) bool {
+				return c.Port == nil && c.Debug == nil && c.Level == nil && c.TLS == nil && c.Files == nil && *c.Name == "app"
+			},
+		},
+		{
+			args: []string{"--port", "0", "-d", "--name", "x"},
+			check: func(c *pointerConfig) bool {
+				return c.Port != nil && *c.Port == 0 && *c.Debug && c.Level == nil && *c.Name == "x"
+			},
+		},
+		{
+			args: nil,
+			env:  []string{"LEVEL=3"},
+			check: func(c *pointerConfig) bool {
+				return c.Level != nil && *c.Level != nil && **c.Level == 3
+			},
+		},
+		{
+			args: []string{"--cert", "c.pem", "a", "b"},
+			check: func(c *pointerConfig) bool {
+				return c.TLS != nil && c.TLS.Cert == "c.pem" && len(*c.Files) == 2
+			},
+		},
+	} {
+		var got pointerConfig
+		if err := New(test.args).SetExit(false).SetEnviron(test.env).Bind(&got); err != nil {
+			t.Fatalf("%q: %v", test.args, err)
+		}
+
+		if !test.check(&got) {
+			t.Errorf("%q %q: got %+v", test.args, test.env, got)
+		}
+	}
+}
+
+// The pointers already allocated by the caller are kept
+func Test_Pointer_Allocated(t *testing.T) {
+	port := 80
+	got := pointerConfig{Port: &port}
+	if err := New([]string{"-p", "81"}).SetExit(false).Bind(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Port != &port || port != 81 {
+		t.Errorf("got %v, need the pointer to 81", got.Port)
+	}
+}
+
+func Test_Pointer_Required(t *testing.T) {
+	type config struct {
+		Token *string `screw:"--token" valid:"required" usage:"token"`
+	}
+
+	var got config
+	if err := New(nil).SetExit(false).SetOutput(ioutil.Discard).Bind(&got); err == nil || !strings.Contains(err.Error(), "--token must have a value") {
+		t.Errorf("got %v, need the required error", err)
+	}
+
+	//The zero value is provided
+	if err := New([]string{"--token", ""}).SetExit(false).Bind(&got); err != nil || got.Token == nil {
+		t.Errorf("got %v %v", err, got.Token)
+	}
+}
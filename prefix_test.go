@@ -0,0 +1,81 @@
+package screw_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/RainFallsSilent/screw"
+	"github.com/RainFallsSilent/screw/screwtest"
+)
+
+type prefixTLS struct {
+	Cert     string `screw:"--cert;env" usage:"certificate file"`
+	Insecure bool   `screw:"--insecure" usage:"skip verification"`
+}
+
+type prefixConfig struct {
+	Debug      bool      `screw:"-d;--debug" usage:"debug"`
+	Upstream   prefixTLS `prefix:"upstream-"`
+	Downstream prefixTLS `prefix:"downstream-" envprefix:"DOWN_"`
+}
+
+func Test_Prefix(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		env  map[string]string
+		need prefixConfig
+	}{
+		{
+			args: []string{"--upstream-cert", "a.pem", "--downstream-insecure"},
+			need: prefixConfig{Upstream: prefixTLS{Cert: "a.pem"}, Downstream: prefixTLS{Insecure: true}},
+		},
+		{
+			args: nil,
+			env:  map[string]string{"UPSTREAM_CERT": "a.pem", "DOWN_CERT": "b.pem", "CERT": "c.pem"},
+			need: prefixConfig{Upstream: prefixTLS{Cert: "a.pem"}, Downstream: prefixTLS{Cert: "b.pem"}},
+		},
+		{
+			args: []string{"-d", "--downstream-cert=b.pem"},
+			need: prefixConfig{Debug: true, Downstream: prefixTLS{Cert: "b.pem"}},
+		},
+		{
+			//The env takes precedence over the command line
+			args: []string{"-d", "--downstream-cert=b.pem"},
+			env:  map[string]string{"DOWN_CERT": "c.pem"},
+			need: prefixConfig{Debug: true, Downstream: prefixTLS{Cert: "c.pem"}},
+		},
+	} {
+		var got prefixConfig
+		screwtest.New(test.args...).Env(test.env).Run(&got).NoError(t)
+		if !reflect.DeepEqual(got, test.need) {
+			t.Errorf("%q %v: got %+v, need %+v", test.args, test.env, got, test.need)
+		}
+	}
+}
+
+func Test_Prefix_Error(t *testing.T) {
+	//The short options can not be prefixed
+	var short struct {
+		Upstream struct {
+			Cert string `screw:"-c;--cert" usage:"certificate file"`
+		} `prefix:"upstream-"`
+	}
+	r := screwtest.New("--upstream-cert", "a.pem").Run(&short).ErrorContains(t, "short option -c can not be used under prefix upstream-")
+	if !errors.Is(r.Err, screw.ErrOptionName) {
+		t.Errorf("got %v", r.Err)
+	}
+
+	//The same structure without a prefix is registered twice
+	var dup struct {
+		Upstream   prefixTLS
+		Downstream prefixTLS
+	}
+	screwtest.New().Run(&dup).ErrorContains(t, screw.ErrDuplicateOptions.Error())
+}
+
+func Test_Prefix_Help(t *testing.T) {
+	screwtest.AssertHelp(t, new(prefixConfig), "testdata/prefix.help", func(s *screw.Screw) {
+		s.SetProcName("app")
+	})
+}
@@ -0,0 +1,127 @@
+package screw
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Prompter asks for the values of the required options that are not set.
+// The options need a prompt tag, such as prompt:"Enter API token"
+type Prompter interface {
+	//Read one value, the secret values must not be echoed
+	Prompt(message string, secret bool) (string, error)
+}
+
+// PromptFunc is an adapter to use a function as a Prompter
+type PromptFunc func(message string, secret bool) (string, error)
+
+func (f PromptFunc) Prompt(message string, secret bool) (string, error) {
+	return f(message, secret)
+}
+
+// Set the Prompter, by default the values are asked for on the terminal if stdin is a terminal
+func (c *Screw) SetPrompter(p Prompter) *Screw {
+	c.prompter = p
+	return c
+}
+
+// Set the Prompter, a Binder of a Spec never reads the terminal, it only prompts with the Prompter
+func (b *Binder) SetPrompter(p Prompter) *Binder {
+	b.prompter = p
+	return b
+}
+
+func isRequired(valid string) bool {
+	for _, v := range strings.Split(valid, ",") {
+		if strings.TrimSpace(v) == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+// Ask for the required options with a prompt tag that are still not set, it runs before the validation
+func (b *Binder) prompt() error {
+	p := b.prompter
+	for _, o := range b.cmd.setOptions(b) {
+		if len(o.prompt) == 0 || !o.required {
+			continue
+		}
+
+		s := b.state(o)
+		if !s.value().IsZero() {
+			continue
+		}
+
+		if p == nil {
+			if !b.terminal || !term.IsTerminal(int(os.Stdin.Fd())) {
+				return nil
+			}
+			p = newTerminalPrompter(os.Stdin, os.Stderr)
+		}
+
+		v, err := p.Prompt(o.prompt, o.secret)
+		if err != nil {
+			return err
+		}
+
+		if err := setValueAndIndex(v, o.envSep(), s, 0, 0); err != nil {
+			return err
+		}
+		s.setSource(SourcePrompt, v, "", -1)
+	}
+	return nil
+}
+
+// terminalPrompter reads the lines of the terminal, the secret values are read without echo.
+// The lines are read byte by byte, the input after the line is left to the next prompt, such as a secret one
+type terminalPrompter struct {
+	in  *os.File
+	out io.Writer
+}
+
+func newTerminalPrompter(in *os.File, out io.Writer) *terminalPrompter {
+	return &terminalPrompter{in: in, out: out}
+}
+
+func (t *terminalPrompter) Prompt(message string, secret bool) (string, error) {
+	fmt.Fprintf(t.out, "%s: ", message)
+
+	if secret {
+		b, err := term.ReadPassword(int(t.in.Fd()))
+		fmt.Fprintln(t.out)
+		return string(b), err
+	}
+
+	line, err := readLine(t.in)
+	if err != nil && (err != io.EOF || len(line) == 0) {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Read up to and including the newline without reading ahead
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n > 0 {
+			line = append(line, b[0])
+			if b[0] == '\n' {
+				return string(line), nil
+			}
+		}
+		if err != nil {
+			return string(line), err
+		}
+	}
+}
+
+func SetPrompter(p Prompter) {
+	CommandLine.SetPrompter(p)
+}
@@ -0,0 +1,146 @@
+package screw
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+type promptConfig struct {
+	User  string `screw:"--user" prompt:"User" valid:"required" usage:"user"`
+	Token string `screw:"--token;secret" prompt:"Token" valid:"required" usage:"token"`
+	Host  string `screw:"--host" prompt:"Host" usage:"host"`
+}
+
+// Answer the prompts from the map and record the messages and whether they are secret
+type promptRecorder struct {
+	answers map[string]string
+	asked   []string
+}
+
+func (p *promptRecorder) Prompt(message string, secret bool) (string, error) {
+	if secret {
+		p.asked = append(p.asked, message+" (secret)")
+	} else {
+		p.asked = append(p.asked, message)
+	}
+
+	v, ok := p.answers[message]
+	if !ok {
+		return "", errors.New("no answer for " + message)
+	}
+	return v, nil
+}
+
+func Test_Prompt(t *testing.T) {
+	for _, test := range []struct {
+		args  []string
+		asked []string
+		need  promptConfig
+	}{
+		{
+			//The optional options are not asked for
+			args:  nil,
+			asked: []string{"User", "Token (secret)"},
+			need:  promptConfig{User: "u", Token: "t"},
+		},
+		{
+			args:  []string{"--user", "x"},
+			asked: []string{"Token (secret)"},
+			need:  promptConfig{User: "x", Token: "t"},
+		},
+		{
+			args: []string{"--user", "x", "--token", "y"},
+			need: promptConfig{User: "x", Token: "y"},
+		},
+	} {
+		p := &promptRecorder{answers: map[string]string{"User": "u", "Token": "t"}}
+		var got promptConfig
+		if err := New(test.args).SetExit(false).SetPrompter(p).Bind(&got); err != nil {
+			t.Fatalf("%q: %v", test.args, err)
+		}
+
+		if got != test.need || strings.Join(p.asked, ";") != strings.Join(test.asked, ";") {
+			t.Errorf("%q: got %+v asked %q, need %+v asked %q", test.args, got, p.asked, test.need, test.asked)
+		}
+	}
+}
+
+func Test_Prompt_Error(t *testing.T) {
+	p := &promptRecorder{answers: map[string]string{"User": "u"}}
+	var got promptConfig
+	err := New(nil).SetExit(false).SetOutput(ioutil.Discard).SetPrompter(p).Bind(&got)
+	if err == nil || err.Error() != "no answer for Token" {
+		t.Errorf("got %v", err)
+	}
+}
+
+// A Binder of a Spec only prompts with a Prompter, it never reads stdin
+func Test_Prompt_Spec(t *testing.T) {
+	spec, err := New(nil).SetExit(false).SetOutput(ioutil.Discard).Compile(new(promptConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if spec.NewBinder(nil).terminal {
+		t.Error("the Binder of a Spec reads the terminal")
+	}
+
+	var got promptConfig
+	if err := spec.NewBinder(nil).Bind(&got); err == nil || !strings.Contains(err.Error(), "--user must have a value") {
+		t.Errorf("got %v, need the required error", err)
+	}
+
+	p := &promptRecorder{answers: map[string]string{"User": "u", "Token": "t"}}
+	if err := spec.NewBinder(nil).SetPrompter(p).Bind(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.User != "u" || got.Token != "t" {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func Test_Prompt_Source(t *testing.T) {
+	p := &promptRecorder{answers: map[string]string{"User": "u", "Token": "t"}}
+	var got promptConfig
+	s := New(nil).SetExit(false).SetPrompter(p)
+	if err := s.Bind(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	if src := s.Source("User"); src.Kind != SourcePrompt || src.Raw != "u" {
+		t.Errorf("got %+v", src)
+	}
+	//The secret values are redacted
+	if src := s.Source("Token"); src.Kind != SourcePrompt || src.Raw == "t" {
+		t.Errorf("got %+v", src)
+	}
+}
+
+// The terminal prompter does not read ahead, the input after the line is left to the secret prompt
+func Test_Prompt_Terminal(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if _, err := w.WriteString("u\r\ns3cret\n"); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	var out bytes.Buffer
+	v, err := newTerminalPrompter(r, &out).Prompt("User", false)
+	if err != nil || v != "u" || out.String() != "User: " {
+		t.Fatalf("got %q %v, output %q", v, err, out.String())
+	}
+
+	rest, err := ioutil.ReadAll(r)
+	if err != nil || string(rest) != "s3cret\n" {
+		t.Errorf("got %q %v", rest, err)
+	}
+}
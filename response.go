@@ -0,0 +1,217 @@
+package screw
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// Enable the expansion of @file arguments, the file holds more arguments split by the shell rules, see SetResponseFileLines.
+// Files can include other files, a relative file name is relative to the working directory
+func (c *Screw) SetResponseFiles(enable bool) *Screw {
+	c.responseFiles = enable
+	return c
+}
+
+// See Screw.SetResponseFiles
+func (b *Binder) SetResponseFiles(enable bool) *Binder {
+	b.responseFiles = enable
+	return b
+}
+
+// Read one argument per line from the response files instead of the shell rules, the lines are taken literally,
+// so the spaces and quotes are kept. The empty lines are skipped and a line like @file still includes the file.
+// It enables the response files
+func (c *Screw) SetResponseFileLines(enable bool) *Screw {
+	c.responseFiles = c.responseFiles || enable
+	c.responseLines = enable
+	return c
+}
+
+// See Screw.SetResponseFileLines
+func (b *Binder) SetResponseFileLines(enable bool) *Binder {
+	b.responseFiles = b.responseFiles || enable
+	b.responseLines = enable
+	return b
+}
+
+// The file and the line of an argument read from a response file, the file is empty for the command line
+type argOrigin struct {
+	file string
+	line int
+}
+
+// The error of an argument, it is wrapped once at the level of the argument
+type originError struct {
+	argOrigin
+	err error
+}
+
+func (e *originError) Error() string {
+	if len(e.file) == 0 {
+		return e.err.Error()
+	}
+	return fmt.Sprintf("%s:%d: %s", e.file, e.line, e.err)
+}
+
+func (e *originError) Unwrap() error {
+	return e.err
+}
+
+func isResponseFile(arg string) bool {
+	return len(arg) > 1 && arg[0] == '@'
+}
+
+// Replace the @file arguments with the content of the files
+func (c *cmdParser) expandResponseFiles() error {
+	args := make([]string, 0, len(c.args))
+	origins := make([]argOrigin, 0, len(c.args))
+	var offsets []int
+	expanded := false
+
+	for i, arg := range c.args {
+		words := []string{arg}
+		wordOrigins := []argOrigin{{}}
+		//The value of a secret option is the file of the secret, such as --token @/run/secrets/token
+		if isResponseFile(arg) && (i == 0 || !c.command.isSecretOption(c.args[i-1])) {
+			var err error
+			if words, wordOrigins, err = readResponseFile(arg[1:], nil, c.responseLines); err != nil {
+				return c.argError(i, err)
+			}
+			expanded = true
+		}
+
+		args = append(args, words...)
+		origins = append(origins, wordOrigins...)
+		//The arguments read from a file point to the @file argument
+		if c.offsets != nil {
+			for range words {
+				offsets = append(offsets, c.offsets[i])
+			}
+		}
+	}
+
+	c.args = args
+	if expanded {
+		c.origins = origins
+	}
+	if c.offsets != nil {
+		c.offsets = offsets
+	}
+	return nil
+}
+
+// Whether the argument is a secret option taking the next argument as its value, such as --token, -t or -dt
+func (c *command) isSecretOption(arg string) bool {
+	if len(arg) < 2 || arg[0] != '-' || strings.Contains(arg, "=") {
+		return false
+	}
+
+	long := arg[1] == '-'
+	for _, o := range c.allOptions() {
+		if !o.secret {
+			continue
+		}
+
+		if long {
+			name := arg[2:]
+			if name == o.fileName {
+				return true
+			}
+			for _, n := range o.showLong {
+				if n == name {
+					return true
+				}
+			}
+			continue
+		}
+
+		//The last one of the combined short options takes the value
+		for _, n := range o.showShort {
+			if n == arg[len(arg)-1:] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Read the arguments of the file and where they are, stack holds the files being read to find the cycles
+func readResponseFile(name string, stack []string, lines bool) ([]string, []argOrigin, error) {
+	path, err := filepath.Abs(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, p := range stack {
+		if p == path {
+			return nil, nil, fmt.Errorf("response file cycle: %s", strings.Join(append(stack, path), " -> "))
+		}
+	}
+
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	split := splitWords
+	if lines {
+		split = splitLines
+	}
+	words, offsets, err := split(string(data))
+	if err != nil {
+		var oe *OffsetError
+		if errors.As(err, &oe) {
+			return nil, nil, fmt.Errorf("%s:%d: %w", name, lineOf(data, oe.Offset), oe.Err)
+		}
+		return nil, nil, err
+	}
+
+	stack = append(stack, path)
+	args := make([]string, 0, len(words))
+	origins := make([]argOrigin, 0, len(words))
+	for i, w := range words {
+		line := lineOf(data, offsets[i])
+		if !isResponseFile(w) {
+			args = append(args, w)
+			origins = append(origins, argOrigin{file: name, line: line})
+			continue
+		}
+
+		nested, nestedOrigins, err := readResponseFile(w[1:], stack, lines)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s:%d: %w", name, line, err)
+		}
+		args = append(args, nested...)
+		origins = append(origins, nestedOrigins...)
+	}
+
+	return args, origins, nil
+}
+
+// Every line that is not empty is one argument, the offsets are the starts of the lines
+func splitLines(s string) (words []string, offsets []int, err error) {
+	for start := 0; start < len(s); {
+		end := strings.IndexByte(s[start:], '\n')
+		if end < 0 {
+			end = len(s)
+		} else {
+			end += start
+		}
+
+		if line := strings.TrimSuffix(s[start:end], "\r"); len(line) > 0 {
+			words = append(words, line)
+			offsets = append(offsets, start)
+		}
+		start = end + 1
+	}
+	return words, offsets, nil
+}
+
+// The line number of the byte offset, starting from 1
+func lineOf(data []byte, offset int) int {
+	return bytes.Count(data[:offset], []byte{'\n'}) + 1
+}
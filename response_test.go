@@ -0,0 +1,196 @@
+package screw
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type responseConfig struct {
+	Name  string   `screw:"-n;--name" usage:"name"`
+	Tags  []string `screw:"-t;--tag" usage:"tags"`
+	Files []string `screw:"args=files" usage:"files"`
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range files {
+		data = strings.Replace(data, "$DIR", dir, -1)
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func Test_ResponseFiles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"args.txt":   "--name 'a b' # the name\n-t x\n@$DIR/nested.txt\n",
+		"nested.txt": "-t y file1",
+		"empty.txt":  "",
+	})
+
+	for _, test := range []struct {
+		args []string
+		need responseConfig
+	}{
+		{
+			args: []string{"@" + filepath.Join(dir, "args.txt"), "file2"},
+			need: responseConfig{Name: "a b", Tags: []string{"x", "y"}, Files: []string{"file1", "file2"}},
+		},
+		{
+			args: []string{"-n", "x", "@" + filepath.Join(dir, "empty.txt")},
+			need: responseConfig{Name: "x"},
+		},
+		{
+			//A lone @ is an argument
+			args: []string{"@"},
+			need: responseConfig{Files: []string{"@"}},
+		},
+	} {
+		var got responseConfig
+		spec := MustCompile((*responseConfig)(nil))
+		if err := spec.NewBinder(test.args).SetResponseFiles(true).Bind(&got); err != nil {
+			t.Fatalf("%q: %v", test.args, err)
+		}
+
+		if !reflect.DeepEqual(got, test.need) {
+			t.Errorf("%q: got %+v, need %+v", test.args, got, test.need)
+		}
+	}
+}
+
+func Test_ResponseFiles_Disabled(t *testing.T) {
+	var got responseConfig
+	spec := MustCompile((*responseConfig)(nil))
+	if err := spec.Bind([]string{"@args.txt"}, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got.Files, []string{"@args.txt"}) {
+		t.Errorf("got %q", got.Files)
+	}
+}
+
+func Test_ResponseFiles_Error(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"self.txt":  "-t x @$DIR/self.txt",
+		"a.txt":     "-t a\n@$DIR/b.txt",
+		"b.txt":     "-t b\n\n@$DIR/a.txt",
+		"quote.txt": "-t x\n-n 'unterminated",
+		"bad.txt":   "-t x\n@$DIR/quote.txt",
+	})
+
+	for _, test := range []struct {
+		file string
+		msg  []string
+	}{
+		{file: "self.txt", msg: []string{"response file cycle", "self.txt -> "}},
+		{file: "a.txt", msg: []string{"response file cycle", "a.txt:2", "b.txt:3", "a.txt -> ", "b.txt -> "}},
+		{file: "quote.txt", msg: []string{"quote.txt:2", "unterminated single quote"}},
+		{file: "bad.txt", msg: []string{"bad.txt:2", "quote.txt:2"}},
+		{file: "missing.txt", msg: []string{"missing.txt"}},
+	} {
+		spec := MustCompile((*responseConfig)(nil))
+		err := spec.NewBinder([]string{"@" + filepath.Join(dir, test.file)}).SetResponseFiles(true).Bind(new(responseConfig))
+		if err == nil {
+			t.Errorf("%s: no error", test.file)
+			continue
+		}
+
+		for _, msg := range test.msg {
+			if !strings.Contains(err.Error(), msg) {
+				t.Errorf("%s: %q does not contain %q", test.file, err, msg)
+			}
+		}
+	}
+}
+
+// The parse errors of the arguments read from a file report the file and the line
+func Test_ResponseFiles_Line(t *testing.T) {
+	type config struct {
+		Name  string `screw:"-n" usage:"name"`
+		Level int    `screw:"--level" usage:"level"`
+		Sub   struct {
+			Port int `screw:"-p" usage:"port"`
+		} `screw:"subcommand=sub" usage:"sub"`
+	}
+
+	dir := writeFiles(t, map[string]string{
+		"unknown.txt": "-n x\n\n--bad",
+		"value.txt":   "-n x\n--level abc",
+		"sub.txt":     "sub\n-p 80\n-p x",
+		"nested.txt":  "-n x\n@$DIR/value.txt",
+	})
+
+	for _, test := range []struct {
+		args []string
+		msg  string
+	}{
+		{args: []string{"@" + filepath.Join(dir, "unknown.txt")}, msg: "unknown.txt:3: "},
+		{args: []string{"@" + filepath.Join(dir, "value.txt")}, msg: "value.txt:2: "},
+		{args: []string{"@" + filepath.Join(dir, "sub.txt")}, msg: "sub.txt:3: "},
+		{args: []string{"@" + filepath.Join(dir, "nested.txt")}, msg: "value.txt:2: "},
+	} {
+		spec := MustCompile((*config)(nil))
+		err := spec.NewBinder(test.args).SetResponseFiles(true).Bind(new(config))
+		if err == nil || !strings.HasPrefix(err.Error(), filepath.Join(dir, test.msg)) {
+			t.Errorf("%q: got %v, need %q", test.args, err, test.msg)
+		}
+	}
+
+	//The arguments of the command line have no file
+	dir = writeFiles(t, map[string]string{"sub.txt": "sub"})
+	spec := MustCompile((*config)(nil))
+	err := spec.NewBinder([]string{"@" + filepath.Join(dir, "sub.txt"), "-p", "x"}).SetResponseFiles(true).Bind(new(config))
+	if err == nil || strings.Contains(err.Error(), "sub.txt") {
+		t.Errorf("got %v", err)
+	}
+}
+
+// The arguments read from a file point to the @file argument in the command line string
+func Test_ResponseFiles_Offset(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"args.txt": "-n x\n--bad"})
+
+	spec := MustCompile((*responseConfig)(nil))
+	b, err := spec.NewBinderFromString("file1  @" + filepath.Join(dir, "args.txt") + " -n y")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = b.SetResponseFiles(true).Bind(new(responseConfig))
+	var oe *OffsetError
+	if !errors.As(err, &oe) || oe.Offset != 7 {
+		t.Errorf("got %v, need offset 7", err)
+	}
+}
+
+// One argument per line, the spaces and quotes are kept
+func Test_ResponseFileLines(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"args.txt":   "--name\n'a b' \"c\"\r\n\n-t\n# not a comment\n@$DIR/nested.txt\n",
+		"nested.txt": "file 1\n$HOME",
+		"cycle.txt":  "-t\nx\n@$DIR/cycle.txt",
+	})
+
+	var got responseConfig
+	spec := MustCompile((*responseConfig)(nil))
+	if err := spec.NewBinder([]string{"@" + filepath.Join(dir, "args.txt")}).SetResponseFileLines(true).Bind(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	need := responseConfig{Name: `'a b' "c"`, Tags: []string{"# not a comment"}, Files: []string{"file 1", "$HOME"}}
+	if !reflect.DeepEqual(got, need) {
+		t.Errorf("got %+v, need %+v", got, need)
+	}
+
+	err := New([]string{"@" + filepath.Join(dir, "cycle.txt")}).SetExit(false).SetOutput(ioutil.Discard).
+		SetResponseFileLines(true).Bind(new(responseConfig))
+	if err == nil || !strings.Contains(err.Error(), "response file cycle") || !strings.Contains(err.Error(), "cycle.txt:3") {
+		t.Errorf("got %v", err)
+	}
+}
@@ -8,8 +8,6 @@ import (
 	"reflect"
 	"strings"
 	"unicode/utf8"
-
-	"github.com/go-playground/validator/v10"
 )
 
 var (
@@ -27,11 +25,14 @@ const (
 	defautlVersion      = "v1.0.1"
 	defautlCallbackName = "Parse"
 	defaultSubMain      = "SubMain"
+	defaultSep          = ","
 )
 
 const (
 	optGreedy          = "greedy"
 	optOnce            = "once"
+	optSecret          = "secret"
+	optHidden          = "hidden"
 	optEnv             = "env"
 	optEnvEqual        = "env="
 	optSubcommand      = "subcommand"
@@ -54,30 +55,55 @@ type unparsedArg struct {
 	index int
 }
 
+// Screw registers structures and parses one command line into them.
+// A Screw is single-use, use Compile to get a Spec that can be reused
 type Screw struct {
-	root         *Screw
+	*command
+	args    []string
+	offsets []int
+	structs []reflect.Value
+	binder  *Binder
+
+	responseFiles bool
+	responseLines bool
+	exit          bool
+	w             io.Writer
+	prompter      Prompter
+	lookupEnv     func(string) (string, bool)
+	//The KEY=value list of SetEnviron, it is the env of the plugins
+	environ []string
+}
+
+// command is the compiled form of one command level, the root structures or a subcommand.
+// It is only read once the registration is finished, so it can be shared by many goroutines
+type command struct {
+	parent       *command
 	shortAndLong map[string]*Option
 	checkEnv     map[string]struct{}
 	checkArgs    map[string]struct{}
 	envAndArgs   []*Option
-	args         []string
-	unparsedArgs []unparsedArg
-	allStruct    map[interface{}]struct{}
+	subcommand   map[string]*Subcommand
 
 	about   string
 	version string
 
-	subMain    reflect.Value
-	structAddr reflect.Value
-	exit       bool
-	subcommand map[string]*Subcommand
-
-	isSetSubcommand map[string]struct{}
-	procName        string
-
-	currSubcommandFieldName string
-	fieldName               string
-	w                       io.Writer
+	procName string
+
+	//The location of the subcommand structure and whether it implements SubMain
+	field   fieldPath
+	subMain bool
+
+	//Only the root command uses the following fields
+	types      []reflect.Type
+	defaults   []defaultValue
+	numOptions int
+	envPrefix  string
+	autoEnv    bool
+	//Whether --print-config is a built-in option
+	printConfig bool
+	//The unknown subcommands run the plugins found in pluginDirs or PATH
+	plugins    bool
+	pluginDirs []string
 }
 
 func (c *Screw) SetVersion(version string) *Screw {
@@ -91,21 +117,26 @@ func (c *Screw) SetAbout(about string) *Screw {
 }
 
 type Subcommand struct {
-	*Screw
+	*command
 	usage string
 }
 
 type Option struct {
-	pointer      reflect.Value
-	fn           reflect.Value
+	field        fieldPath
+	typ          reflect.Type
+	id           int
+	fnName       string
 	usage        string
 	showDefValue string
-	//Indicates the parameter priority. The high 4 bytes store the args sequence,
-	//and the low 4 bytes store the command combination sequence (ls ltr).
-	//The value of the high 4 bytes of l here is 0
-	index    uint64
-	envName  string
-	argsName string
+	envName      string
+	argsName     string
+	//The separator of the elements in one value of slices and maps
+	sep string
+	//The layout of the time.Time values
+	layout string
+	//The value must be one of the choices
+	choices    []string
+	ignoreCase bool
 	//Greedy mode - H a b c equals - H a - H b - H c
 	greedy bool
 	//If the once flag is set, the command line will report that
@@ -113,30 +144,75 @@ type Option struct {
 	//It can only be set once. If the once flag is set,
 	//an error will be reported if the command line passes the option twice
 	once      bool
-	cmdSet    bool
 	showShort []string
 	showLong  []string
+	//The prefix of the structure the option belongs to, the options with a prefix are grouped in the help
+	prefix string
+	//The message asking for the value if the option is required and not set
+	prompt   string
+	required bool
+	//The value is not echoed when it is asked for, and it is redacted in the help, errors and dumps
+	secret bool
+	//The long option reading the value of the secret option from a file, such as token-file
+	fileName string
+	//The option is not shown in the help
+	hidden bool
+}
+
+// optionGroup is the prefix of the options of a nested structure, so it can be registered more than once
+type optionGroup struct {
+	prefix    string
+	envPrefix string
 }
 
-func (o *Option) onceResetValue() {
-	if len(o.showDefValue) > 0 && !o.pointer.IsZero() && !o.cmdSet {
-		resetValue(o.pointer)
+// Add the prefix and envprefix tags of the structure field, the env prefix is derived from the prefix if it is not set
+func (g optionGroup) child(sf reflect.StructField) (optionGroup, error) {
+	prefix, hasPrefix := Tag(sf.Tag).Lookup("prefix")
+	if hasPrefix {
+		g.prefix += prefix
 	}
 
-	o.cmdSet = true
+	if envPrefix, ok := Tag(sf.Tag).Lookup("envprefix"); ok {
+		g.envPrefix += envPrefix
+	} else if hasPrefix {
+		envPrefix, err := envOptionName(prefix)
+		if err != nil {
+			return g, err
+		}
+		g.envPrefix += envPrefix
+	}
+	return g, nil
 }
 
-func New(args []string) *Screw {
-	return &Screw{
+// The env value of slices and maps is split by comma if no separator is set
+func (o *Option) envSep() string {
+	if len(o.sep) > 0 {
+		return o.sep
+	}
+	return defaultSep
+}
+
+func (o *Option) kind() reflect.Kind {
+	if o.typ == nil {
+		return reflect.Invalid
+	}
+	return o.typ.Kind()
+}
+
+func newCommand() *command {
+	return &command{
 		shortAndLong: make(map[string]*Option),
 		checkEnv:     make(map[string]struct{}),
 		checkArgs:    make(map[string]struct{}),
-		//TODO needs to optimize the memory, and only root needs to initialize
-		isSetSubcommand: make(map[string]struct{}),
-		allStruct:       make(map[interface{}]struct{}),
-		args:            args,
-		exit:            true,
-		w:               os.Stdout,
+	}
+}
+
+func New(args []string) *Screw {
+	return &Screw{
+		command: newCommand(),
+		args:    args,
+		exit:    true,
+		w:       os.Stdout,
 	}
 }
 
@@ -163,6 +239,49 @@ func (c *Screw) SetOutput(w io.Writer) *Screw {
 	return c
 }
 
+// Set the function reading the env of the options, the help and the plugins, os.LookupEnv by default
+func (c *Screw) SetEnv(lookup func(string) (string, bool)) *Screw {
+	c.lookupEnv = lookup
+	c.environ = nil
+	return c
+}
+
+// Read the env from the KEY=value list instead of the process, such as another process's environment
+func (c *Screw) SetEnviron(environ []string) *Screw {
+	c.SetEnv(Environ(environ))
+	c.environ = append([]string{}, environ...)
+	return c
+}
+
+// Environ returns the lookup function of the KEY=value list, the later values win like in os/exec
+func Environ(environ []string) func(string) (string, bool) {
+	env := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if pos := strings.IndexByte(kv, '='); pos > 0 {
+			env[kv[:pos]] = kv[pos+1:]
+		}
+	}
+
+	return func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+}
+
+// Set the prefix of the env names derived from the field names, such as MYAPP.
+// It must be called before the structures are registered
+func (c *Screw) SetEnvPrefix(prefix string) *Screw {
+	c.envPrefix = prefix
+	return c
+}
+
+// In AutoEnv mode, every long option is also bound to an env name, such as MYAPP_SUB_LONG_NAME.
+// It must be called before the structures are registered
+func (c *Screw) SetAutoEnv(auto bool) *Screw {
+	c.autoEnv = auto
+	return c
+}
+
 // Set Process Name
 func (c *Screw) SetProcName(procName string) *Screw {
 	c.procName = procName
@@ -170,27 +289,20 @@ func (c *Screw) SetProcName(procName string) *Screw {
 }
 
 func (c *Screw) IsSetSubcommand(subcommand string) bool {
-	_, ok := c.isSetSubcommand[subcommand]
-	return ok
+	if c.binder == nil {
+		return false
+	}
+	return c.binder.IsSetSubcommand(subcommand)
 }
 
 func (c *Screw) GetIndex(optName string) uint64 {
-	o, ok := c.shortAndLong[optName]
-	if ok {
-		return o.index
-	}
-	if _, ok := c.checkArgs[optName]; ok {
-		for _, o := range c.envAndArgs {
-			if o.argsName == optName {
-				return o.index
-			}
-		}
+	if c.binder == nil {
+		return 0
 	}
-
-	return 0
+	return c.binder.GetIndex(optName)
 }
 
-func (c *Screw) setOption(name string, option *Option, m map[string]*Option, long bool) error {
+func (c *command) setOption(name string, option *Option, m map[string]*Option, long bool) error {
 	if c, ok := checkOptionName(name); !ok {
 		return fmt.Errorf("%w:%s:unsupported characters found(%c)", ErrOptionName, name, c)
 	}
@@ -207,25 +319,44 @@ func (c *Screw) setOption(name string, option *Option, m map[string]*Option, lon
 	return nil
 }
 
-func setValueAndIndex(val string, option *Option, index int, lowIndex int) error {
+func setValueAndIndex(val string, sep string, option *optionState, index int, lowIndex int) (err error) {
+	if option.secret {
+		defer func(val string) {
+			err = redactError(err, val, sep)
+		}(val)
+	}
+
+	if val, err = option.checkChoices(val, sep); err != nil {
+		return err
+	}
+
 	option.onceResetValue()
 	option.index = uint64(index) << 31
 	option.index |= uint64(lowIndex)
-	if option.fn.IsValid() {
+	if len(option.fnName) > 0 {
 		//If a callback is defined, the default form of
-		option.fn.Call([]reflect.Value{reflect.ValueOf(val)})
+		option.callback().Call([]reflect.Value{reflect.ValueOf(val)})
 		return nil
 	}
 
-	return setBase(val, option.pointer)
+	if option.once && option.kind() == reflect.Map {
+		return setMapPairs(val, sep, option.settable(), true)
+	}
+
+	if option.kind() == reflect.Array {
+		return option.setArrayValue(val, sep)
+	}
+
+	return setLayoutValue(val, sep, option.layout, option.settable())
 }
 
+// The name is the option without its value, so a secret is never shown, such as --token or -t
 func errOnce(optionName string) error {
-	return fmt.Errorf(`error: The argument '-%s' was provided more than once, but cannot be used multiple times`,
+	return fmt.Errorf(`error: The argument '%s' was provided more than once, but cannot be used multiple times`,
 		optionName)
 }
 
-func (c *Screw) unknownOptionErrorShort(optionName string, arg string) error {
+func (c *command) unknownOptionErrorShort(optionName string, arg string) error {
 	m := fmt.Sprintf(`error: Found argument '-%s' which wasn't expected, or isn't valid in this context`,
 		optionName)
 
@@ -233,7 +364,7 @@ func (c *Screw) unknownOptionErrorShort(optionName string, arg string) error {
 	return errors.New(m)
 }
 
-func (c *Screw) unknownOptionError(optionName string) error {
+func (c *command) unknownOptionError(optionName string) error {
 	m := fmt.Sprintf(`error: Found argument '--%s' which wasn't expected, or isn't valid in this context`,
 		optionName)
 
@@ -259,28 +390,29 @@ func setBoolAndBoolSliceDefval(pointer reflect.Value, value *string) {
 
 }
 
-func (c *Screw) parseEqualValue(arg string) (value string, option *Option, err error) {
+func (c *cmdParser) parseEqualValue(arg string) (value string, option *optionState, err error) {
 	pos := strings.Index(arg, "=")
 	if pos == -1 {
 		return "", nil, c.unknownOptionError(arg)
 	}
 
-	option, _ = c.shortAndLong[arg[:pos]]
-	if option == nil {
+	o, _ := c.shortAndLong[arg[:pos]]
+	if o == nil {
 		return "", nil, c.unknownOptionError(arg)
 	}
 	value = arg[pos+1:]
-	return value, option, nil
+	return value, c.state(o), nil
 }
 
-func checkOnce(arg string, option *Option) error {
-	if option.once && !option.pointer.IsZero() {
-		return errOnce(arg)
+func checkOnce(name string, option *optionState) error {
+	//The map can be set many times, the keys are checked when they are set
+	if option.once && !option.value().IsZero() && option.kind() != reflect.Map {
+		return errOnce(name)
 	}
 	return nil
 }
 
-func (c *Screw) isRegisterOptions(arg string) bool {
+func (c *command) isRegisterOptions(arg string) bool {
 	num := 0
 	if len(arg) > 0 && arg[0] == '-' {
 		num++
@@ -297,15 +429,20 @@ func (c *Screw) isRegisterOptions(arg string) bool {
 	}
 
 	_, ok := c.shortAndLong[arg[num:end]]
+	//The short option can be followed by its value, such as -Dkey=value or -p8080
+	if !ok && num == 1 && end > 1 {
+		_, ok = c.shortAndLong[arg[1:2]]
+	}
 	return ok
 }
 
 // Parse long options
-func (c *Screw) parseLong(arg string, index *int) (err error) {
-	var option *Option
+func (c *cmdParser) parseLong(arg string, index *int) (err error) {
+	var option *optionState
 	value := ""
-	option, _ = c.shortAndLong[arg]
-	if option == nil {
+	if o, _ := c.shortAndLong[arg]; o != nil {
+		option = c.state(o)
+	} else {
 		if value, option, err = c.parseEqualValue(arg); err != nil {
 			return err
 		}
@@ -316,13 +453,18 @@ func (c *Screw) parseLong(arg string, index *int) (err error) {
 	}
 
 	//Set the default values of bool and bool slice
-	setBoolAndBoolSliceDefval(option.pointer, &value)
+	setBoolAndBoolSliceDefval(option.value(), &value)
 
 	if len(value) > 0 {
-		if err := checkOnce(arg, option); err != nil {
+		if err := checkOnce("--"+strings.SplitN(arg, "=", 2)[0], option); err != nil {
+			return err
+		}
+		value = option.fileValue(strings.SplitN(arg, "=", 2)[0], value)
+		if err := setArgValue(value, option.sep, option, *index, 0); err != nil {
 			return err
 		}
-		return setValueAndIndex(value, option, *index, 0)
+		c.record(option, SourceFlag, "--"+strings.SplitN(arg, "=", 2)[0], "--"+arg, value, *index, 0)
+		return nil
 	}
 
 	//If it is a long option
@@ -343,13 +485,15 @@ func (c *Screw) parseLong(arg string, index *int) (err error) {
 			return nil
 		}
 
-		if err := checkOnce(arg, option); err != nil {
+		if err := checkOnce("--"+arg, option); err != nil {
 			return err
 		}
 
-		if err := setValueAndIndex(value, option, *index, 0); err != nil {
+		value = option.fileValue(arg, value)
+		if err := setArgValue(value, option.sep, option, *index, 0); err != nil {
 			return err
 		}
+		c.record(option, SourceFlag, "--"+arg, "--"+arg, value, *index, 0)
 
 		/*
 			if option.pointer.Kind() != reflect.Slice && !option.greedy {
@@ -361,21 +505,31 @@ func (c *Screw) parseLong(arg string, index *int) (err error) {
 			return nil
 		}
 	}
-
-	return nil
 }
 
 // Setting environment variables and parameters
-func (o *Option) setEnvAndArgs(c *Screw) (err error) {
+func (o *optionState) setEnvAndArgs(c *cmdParser) (err error) {
+	//The environment variable takes precedence over the command line
 	if len(o.envName) > 0 {
-		if v, ok := os.LookupEnv(o.envName); ok {
-			if o.pointer.Kind() == reflect.Bool {
+		if v, ok := c.getEnv(o.envName); ok {
+			if o.kind() == reflect.Bool {
 				if v != "false" {
 					v = "true"
 				}
 			}
 
-			return setValueAndIndex(v, o, 0, 0)
+			//The env value replaces the values of the command line, they are not merged,
+			//an array is filled again from the first element
+			if o.cmdSet && (o.kind() == reflect.Slice || o.kind() == reflect.Map || o.kind() == reflect.Array) {
+				resetValue(o.settable())
+				o.arrayLen = 0
+			}
+
+			if err := setValueAndIndex(v, o.envSep(), o, 0, 0); err != nil {
+				return err
+			}
+			o.setSource(SourceEnv, v, o.envName, -1)
+			return nil
 		}
 	}
 
@@ -387,10 +541,13 @@ func (o *Option) setEnvAndArgs(c *Screw) (err error) {
 		}
 
 		value := c.unparsedArgs[0]
-		switch o.pointer.Kind() {
+		switch o.kind() {
 		case reflect.Slice:
-			for o.pointer.Kind() == reflect.Slice {
-				setValueAndIndex(value.arg, o, value.index, 0)
+			for o.kind() == reflect.Slice {
+				if err := setArgValue(value.arg, o.sep, o, value.index, 0); err != nil {
+					return c.argError(value.index, err)
+				}
+				c.record(o, SourcePositional, "<"+o.argsName+">", value.arg, value.arg, value.index, 0)
 				c.unparsedArgs = c.unparsedArgs[1:]
 				if len(c.unparsedArgs) == 0 {
 					break
@@ -398,10 +555,21 @@ func (o *Option) setEnvAndArgs(c *Screw) (err error) {
 
 				value = c.unparsedArgs[0]
 			}
+		case reflect.Array:
+			//The array takes the arguments up to its length
+			for n := o.typ.Len(); n > 0 && len(c.unparsedArgs) > 0; n-- {
+				value = c.unparsedArgs[0]
+				if err := setArgValue(value.arg, o.sep, o, value.index, 0); err != nil {
+					return c.argError(value.index, err)
+				}
+				c.record(o, SourcePositional, "<"+o.argsName+">", value.arg, value.arg, value.index, 0)
+				c.unparsedArgs = c.unparsedArgs[1:]
+			}
 		default:
-			if err := setValueAndIndex(value.arg, o, value.index, 0); err != nil {
-				return err
+			if err := setArgValue(value.arg, o.sep, o, value.index, 0); err != nil {
+				return c.argError(value.index, err)
 			}
+			c.record(o, SourcePositional, "<"+o.argsName+">", value.arg, value.arg, value.index, 0)
 			if len(c.unparsedArgs) > 0 {
 				c.unparsedArgs = c.unparsedArgs[1:]
 			}
@@ -411,9 +579,9 @@ func (o *Option) setEnvAndArgs(c *Screw) (err error) {
 	return nil
 }
 
-func (c *Screw) parseShort(arg string, index *int) error {
+func (c *cmdParser) parseShort(arg string, index *int) error {
 	var (
-		option     *Option
+		option     *optionState
 		shortIndex int
 	)
 
@@ -425,34 +593,44 @@ func (c *Screw) parseShort(arg string, index *int) error {
 	//- d=false - d is bool false is value
 	//- f file - f is a string type, and file is a value
 	for shortIndex, a = range arg {
+		pos := shortIndex
 		//Only ascii is supported
 		if a >= utf8.RuneSelf {
 			return errors.New("Illegal character set")
 		}
 
 		optionName := string(byte(a))
-		option, _ = c.shortAndLong[optionName]
-		if option == nil {
+		o, _ := c.shortAndLong[optionName]
+		if o == nil {
 			//没有注册过的选项直接报错
 			return c.unknownOptionErrorShort(optionName, arg)
 		}
+		option = c.state(o)
 
 		find = true
 		findEqual := false //Whether equal sign is found
 		value := arg
-		_, isBoolSlice := option.pointer.Interface().([]bool)
-		_, isBool := option.pointer.Interface().(bool)
+		_, isBoolSlice := option.value().Interface().([]bool)
+		_, isBool := option.value().Interface().(bool)
 		if !(isBoolSlice || isBool) {
 			shortIndex++
 		}
 
-		if len(value[shortIndex:]) > 0 && len(value[shortIndex+1:]) > 0 {
+		if option.kind() == reflect.Map {
+			//The rest of the argument is the key=value pair, such as -Dkey=value or -D=key=value
+			if len(value[shortIndex:]) > 0 {
+				findEqual = true
+				if value[shortIndex] == '=' {
+					shortIndex++
+				}
+			}
+		} else if len(value[shortIndex:]) > 0 && len(value[shortIndex+1:]) > 0 {
 			if value[shortIndex:][0] == '=' {
 				findEqual = true
 				shortIndex++
 			}
 
-			if value[shortIndex+1:][0] == '=' {
+			if len(value[shortIndex+1:]) > 0 && value[shortIndex+1:][0] == '=' {
 				findEqual = true
 				shortIndex += 2
 			}
@@ -471,13 +649,14 @@ func (c *Screw) parseShort(arg string, index *int) error {
 					val = string(value[shortIndex:])
 				}
 
-				if err := checkOnce(value[shortIndex:], option); err != nil {
+				if err := checkOnce("-"+optionName, option); err != nil {
 					return err
 				}
 
-				if err := setValueAndIndex(val, option, *index, shortIndex); err != nil {
+				if err := setArgValue(val, option.sep, option, *index, shortIndex); err != nil {
 					return err
 				}
+				c.record(option, SourceFlag, "-"+optionName, "-"+arg, val, *index, pos+1)
 
 				if findEqual {
 					return nil
@@ -522,7 +701,7 @@ func (c *Screw) parseShort(arg string, index *int) error {
 	return c.unknownOptionErrorShort(arg, arg)
 }
 
-func (c *Screw) findFallbackOpt(value string, index *int) bool {
+func (c *cmdParser) findFallbackOpt(value string, index *int) bool {
 
 	//If greedy mode is turned on, it will not end until - or the last character is encountered
 	if strings.HasPrefix(value, "-") {
@@ -536,7 +715,7 @@ func (c *Screw) findFallbackOpt(value string, index *int) bool {
 	return false
 }
 
-func (c *Screw) getOptionAndSet(arg string, index *int, numMinuses int) error {
+func (c *cmdParser) getOptionAndSet(arg string, index *int, numMinuses int) error {
 	if arg == "h" || arg == "help" {
 		if _, ok := c.shortAndLong[arg]; !ok {
 			c.Usage()
@@ -550,6 +729,13 @@ func (c *Screw) getOptionAndSet(arg string, index *int, numMinuses int) error {
 			return nil
 		}
 	}
+
+	if arg == optPrintConfig && numMinuses == 2 && c.getRoot().printConfig {
+		if _, ok := c.shortAndLong[arg]; !ok {
+			c.showConfig = true
+			return nil
+		}
+	}
 	//Take out the option object
 	switch numMinuses {
 	case 2: //Long Options
@@ -563,9 +749,16 @@ func (c *Screw) getOptionAndSet(arg string, index *int, numMinuses int) error {
 
 // ENV_NAME=
 // ENV_NAME
-func (o *Option) genShowEnvNameValue() (env string) {
+func (o *Option) genShowEnvNameValue(lookupEnv func(string) (string, bool)) (env string) {
 	if len(o.envName) > 0 {
-		envValue := os.Getenv(o.envName)
+		if o.secret {
+			return o.envName
+		}
+
+		if lookupEnv == nil {
+			lookupEnv = os.LookupEnv
+		}
+		envValue, _ := lookupEnv(o.envName)
 		env = o.envName
 		if len(envValue) > 0 {
 			env = env + "=" + envValue
@@ -574,7 +767,7 @@ func (o *Option) genShowEnvNameValue() (env string) {
 	return
 }
 
-func (c *Screw) showShortAndLong(v *Option) string {
+func (c *command) showShortAndLong(v *Option) string {
 	var oneArgs []string
 
 	for _, v := range v.showShort {
@@ -584,31 +777,42 @@ func (c *Screw) showShortAndLong(v *Option) string {
 	for _, v := range v.showLong {
 		oneArgs = append(oneArgs, "--"+v)
 	}
+
+	if len(v.fileName) > 0 {
+		oneArgs = append(oneArgs, "--"+v.fileName)
+	}
 	return strings.Join(oneArgs, ",")
 }
 
-func (c *Screw) genHelpMessage(h *Help) {
+// The env values are read by lookupEnv, os.LookupEnv if it is nil
+func (c *command) genHelpMessage(h *Help, lookupEnv func(string) (string, bool)) {
 
 	//ShortAndLong Multiple keys point to one option, which requires used map de duplication
 	used := make(map[*Option]struct{}, len(c.shortAndLong))
 
+	//The built-in options are only shown, so they are not added to the compiled command
+	builtin := make(map[string]*Option, 2)
 	if c.shortAndLong["h"] == nil && c.shortAndLong["help"] == nil {
-		c.shortAndLong["h"] = &Option{usage: "print the help information", showShort: []string{"h"}, showLong: []string{"help"}}
+		builtin["h"] = &Option{usage: "print the help information", showShort: []string{"h"}, showLong: []string{"help"}}
 	}
 
 	if c.shortAndLong["v"] == nil && c.shortAndLong["version"] == nil {
-		c.shortAndLong["v"] = &Option{usage: "print version information", showShort: []string{"v"}, showLong: []string{"version"}}
+		builtin["v"] = &Option{usage: "print version information", showShort: []string{"v"}, showLong: []string{"version"}}
+	}
+
+	if c.getRoot().printConfig && c.shortAndLong[optPrintConfig] == nil {
+		builtin[optPrintConfig] = &Option{usage: "print the resolved configuration and the source of each value", showLong: []string{optPrintConfig}}
 	}
 
 	saveHelp := func(options map[string]*Option) {
 		for _, v := range options {
-			if _, ok := used[v]; ok {
+			if _, ok := used[v]; ok || v.hidden {
 				continue
 			}
 
 			used[v] = struct{}{}
 
-			env := v.genShowEnvNameValue()
+			env := v.genShowEnvNameValue(lookupEnv)
 
 			opt := c.showShortAndLong(v)
 
@@ -616,18 +820,29 @@ func (c *Screw) genHelpMessage(h *Help) {
 				h.MaxNameLen = len(opt)
 			}
 
-			switch v.pointer.Kind() {
+			choices := strings.Join(v.choices, ", ")
+			if len(v.prefix) > 0 {
+				h.addGroupOption(v.prefix, showOption{Opt: opt, Usage: v.usage, Env: env, Default: v.redact(v.showDefValue), Choices: choices})
+				continue
+			}
+
+			switch v.kind() {
 			case reflect.Bool:
-				h.Flags = append(h.Flags, showOption{Opt: opt, Usage: v.usage, Env: env, Default: v.showDefValue})
+				h.Flags = append(h.Flags, showOption{Opt: opt, Usage: v.usage, Env: env, Default: v.redact(v.showDefValue), Choices: choices})
 			default:
-				h.Options = append(h.Options, showOption{Opt: opt, Usage: v.usage, Env: env, Default: v.showDefValue})
+				h.Options = append(h.Options, showOption{Opt: opt, Usage: v.usage, Env: env, Default: v.redact(v.showDefValue), Choices: choices})
 			}
 		}
 	}
 
 	saveHelp(c.shortAndLong)
+	saveHelp(builtin)
 
 	for _, v := range c.envAndArgs {
+		if v.hidden {
+			continue
+		}
+
 		opt := v.argsName
 		if len(opt) == 0 && len(v.envName) > 0 {
 			opt = v.envName
@@ -642,13 +857,13 @@ func (c *Screw) genHelpMessage(h *Help) {
 			h.MaxNameLen = len(opt)
 		}
 
-		env := v.genShowEnvNameValue()
+		env := v.genShowEnvNameValue(lookupEnv)
 		if len(env) > 0 {
 			h.Envs = append(h.Envs, showOption{Opt: oldOpt, Usage: v.usage, Env: env})
 			continue
 		}
 
-		h.Args = append(h.Args, showOption{Opt: opt, Usage: v.usage, Env: env})
+		h.Args = append(h.Args, showOption{Opt: opt, Usage: v.usage, Env: env, Choices: strings.Join(v.choices, ", ")})
 	}
 
 	//Sub command
@@ -658,6 +873,7 @@ func (c *Screw) genHelpMessage(h *Help) {
 		}
 		h.Subcommand = append(h.Subcommand, showOption{Opt: opt, Usage: v.usage})
 	}
+	c.pluginHelp(h, lookupEnv)
 
 	h.ProcessName = c.procName
 	h.Version = c.version
@@ -666,41 +882,48 @@ func (c *Screw) genHelpMessage(h *Help) {
 }
 
 // Display version information
-func (c *Screw) showVersion() {
+func (c *cmdParser) showVersion() {
 	fmt.Fprintln(c.w, c.version)
 	if c.exit {
 		os.Exit(0)
 	}
 }
 
+func (c *cmdParser) Usage() {
+	c.printHelpMessage(c.w, c.lookupEnv)
+	if c.exit {
+		os.Exit(0)
+	}
+}
+
 func (c *Screw) Usage() {
-	c.printHelpMessage()
+	c.printHelpMessage(c.w, c.lookupEnv)
 	if c.exit {
 		os.Exit(0)
 	}
 }
 
-func (c *Screw) printHelpMessage() {
+func (c *command) printHelpMessage(w io.Writer, lookupEnv func(string) (string, bool)) {
 	h := Help{}
 
-	c.genHelpMessage(&h)
+	c.genHelpMessage(&h, lookupEnv)
 
-	err := h.output(c.w)
+	err := h.output(w)
 	if err != nil {
 		panic(err)
 	}
 
 }
 
-func (c *Screw) getRoot() (root *Screw) {
+func (c *command) getRoot() (root *command) {
 	root = c
-	if c.root != nil {
-		root = c.root
+	for root.parent != nil {
+		root = root.parent
 	}
 	return root
 }
 
-func (c *Screw) parseSubcommandTag(screw string, v reflect.Value, usage string, fieldName string) (newScrew *Screw, haveSubcommand bool) {
+func (c *command) parseSubcommandTag(screw string, t reflect.Type, usage string, fieldName string, field fieldPath) (*command, bool) {
 	options := strings.Split(screw, ";")
 	for _, opt := range options {
 		var name string
@@ -715,25 +938,24 @@ func (c *Screw) parseSubcommandTag(screw string, v reflect.Value, usage string,
 				c.subcommand = make(map[string]*Subcommand, 3)
 			}
 
-			newScrew := New(nil)
-			//newScrew.exit = c.exit //Inherit exit attribute
-			newScrew.SetProcName(name)
-			newScrew.root = c.getRoot()
-			c.subcommand[name] = &Subcommand{Screw: newScrew, usage: usage}
-			newScrew.fieldName = fieldName
+			sub := newCommand()
+			sub.procName = name
+			sub.parent = c
+			sub.field = field
+			c.subcommand[name] = &Subcommand{command: sub, usage: usage}
 
-			newScrew.subMain = v.Addr().MethodByName(defaultSubMain)
-			return newScrew, true
+			_, sub.subMain = reflect.PtrTo(t).MethodByName(defaultSubMain)
+			return sub, true
 		}
 	}
 
 	return nil, false
 }
 
-func (c *Screw) parseTagAndSetOption(screw string, usage string, def string, fieldName string, v reflect.Value) (err error) {
+func (c *command) parseTagAndSetOption(screw string, fieldName string, owner reflect.Type, option *Option, group optionGroup) (err error) {
 	options := strings.Split(screw, ";")
 
-	option := &Option{usage: usage, pointer: v, showDefValue: def}
+	root := c.getRoot()
 
 	const (
 		isShort = 1 << iota
@@ -756,11 +978,12 @@ func (c *Screw) parseTagAndSetOption(screw string, usage string, def string, fie
 			if strings.HasPrefix(opt, optCallbackEqual) {
 				funcName = opt[len(optCallbackEqual):]
 			}
-			option.fn = c.structAddr.MethodByName(funcName)
-			//Check the parameter length of callback
-			if option.fn.Type().NumIn() != 1 {
+			//Check the parameter length of callback, the receiver is the first parameter
+			fn, ok := reflect.PtrTo(owner).MethodByName(funcName)
+			if !ok || fn.Type.NumIn() != 2 {
 				panic(fmt.Sprintf("Required function parameters->%s(val string)", funcName))
 			}
+			option.fnName = funcName
 
 		//Registrar Option -- name
 		case strings.HasPrefix(opt, "--"):
@@ -773,6 +996,7 @@ func (c *Screw) parseTagAndSetOption(screw string, usage string, def string, fie
 				}
 			}
 
+			name = group.prefix + name
 			if err := c.setOption(name, option, c.shortAndLong, true); err != nil {
 				return err
 			}
@@ -790,6 +1014,11 @@ func (c *Screw) parseTagAndSetOption(screw string, usage string, def string, fie
 				name = string(name[0])
 			}
 
+			//The short options can not be prefixed
+			if len(group.prefix) > 0 {
+				return fmt.Errorf("%w:short option -%s can not be used under prefix %s", ErrOptionName, name, group.prefix)
+			}
+
 			if err := c.setOption(name, option, c.shortAndLong, false); err != nil {
 				return err
 			}
@@ -799,23 +1028,25 @@ func (c *Screw) parseTagAndSetOption(screw string, usage string, def string, fie
 			option.greedy = true
 		case strings.HasPrefix(opt, optOnce):
 			option.once = true
+		case opt == optSecret:
+			option.secret = true
+		case opt == optHidden:
+			option.hidden = true
 		case opt == optEnv:
-			if name, err = envOptionName(fieldName); err != nil {
+			if name, err = c.envName(fieldName, group.envPrefix); err != nil {
 				return err
 			}
 			fallthrough
 		case strings.HasPrefix(opt, optEnvEqual):
 			flags |= isEnv
 			if strings.HasPrefix(opt, optEnvEqual) {
-				name = opt[4:]
+				name = group.envPrefix + opt[4:]
 			}
 
 			option.envName = name
-			if _, ok := c.checkEnv[option.envName]; ok {
-				return fmt.Errorf("%s: env=%s", ErrDuplicateOptions, option.envName)
+			if err := c.setEnv(option); err != nil {
+				return err
 			}
-			c.envAndArgs = append(c.envAndArgs, option)
-			c.checkEnv[option.envName] = struct{}{}
 		case strings.HasPrefix(opt, "args="):
 			//Args is mutually exclusive with long and short options
 			if flags&isShort > 0 || flags&isLong > 0 {
@@ -823,7 +1054,7 @@ func (c *Screw) parseTagAndSetOption(screw string, usage string, def string, fie
 			}
 
 			flags |= isArgs
-			option.argsName = opt[5:]
+			option.argsName = group.prefix + opt[5:]
 			if _, ok := c.checkArgs[option.argsName]; ok {
 				return fmt.Errorf("%s: args=%s", ErrDuplicateOptions, option.argsName)
 			}
@@ -845,34 +1076,111 @@ func (c *Screw) parseTagAndSetOption(screw string, usage string, def string, fie
 		return fmt.Errorf("%s:%s", ErrNotFoundName, screw)
 	}
 
+	//The secret option can also be read from a file, such as --token-file
+	if option.secret && len(option.showLong) > 0 {
+		option.fileName = option.showLong[0] + "-file"
+		if err := c.setOption(option.fileName, option, c.shortAndLong, true); err != nil {
+			return err
+		}
+	}
+
+	//In AutoEnv mode, every long option gets an env name
+	if root.autoEnv && len(option.envName) == 0 && len(option.showLong) > 0 {
+		long := strings.TrimPrefix(option.showLong[0], group.prefix)
+		if option.envName, err = c.envName(long, group.envPrefix); err != nil {
+			return err
+		}
+		return c.setEnv(option)
+	}
+
+	return nil
+}
+
+// The env names are checked in the whole tree, the names derived at different levels can be the same,
+// such as MYAPP_SUB_LONG of --sub-long and of --long in the subcommand sub
+func (c *command) setEnv(option *Option) error {
+	checkEnv := c.getRoot().checkEnv
+	if _, ok := checkEnv[option.envName]; ok {
+		return fmt.Errorf("%s: env=%s", ErrDuplicateOptions, option.envName)
+	}
+	c.envAndArgs = append(c.envAndArgs, option)
+	checkEnv[option.envName] = struct{}{}
 	return nil
 }
 
-func (c *Screw) registerCore(v reflect.Value, sf reflect.StructField) error {
-	for v.Kind() == reflect.Ptr {
-		v = v.Elem()
+// Derive the env name with envOptionName.
+// With a prefix or in AutoEnv mode, the name is namespaced by the prefix and the subcommands, such as MYAPP_SUB_LONG_NAME.
+// The group is the env prefix of the nested structure, it comes right before the name
+func (c *command) envName(name string, group string) (string, error) {
+	env, err := envOptionName(name)
+	if err != nil {
+		return "", err
+	}
+	env = group + env
+
+	root := c.getRoot()
+	if len(root.envPrefix) == 0 && !root.autoEnv {
+		return env, nil
+	}
+
+	for p := c; p.parent != nil; p = p.parent {
+		sub, err := envOptionName(p.procName)
+		if err != nil {
+			return "", err
+		}
+		env = sub + "_" + env
+	}
+
+	if len(root.envPrefix) > 0 {
+		env = strings.TrimSuffix(root.envPrefix, "_") + "_" + env
+	}
+	return env, nil
+}
+
+func (c *command) registerCore(t reflect.Type, sf reflect.StructField, owner reflect.Type, field fieldPath, group optionGroup) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
 	}
 
 	screw := Tag(sf.Tag).Get("screw")
 	usage := Tag(sf.Tag).Get("usage")
 
+	//The structure that parses itself is an option, not a group of options
+	isStruct := t.Kind() == reflect.Struct && !isSelfParsing(t)
+
 	//If it is a subcommand
-	if v.Kind() == reflect.Struct {
+	if isStruct {
 		if len(screw) != 0 {
-			if newScrew, b := c.parseSubcommandTag(screw, v, usage, sf.Name); b {
-				c = newScrew
+			if newCommand, b := c.parseSubcommandTag(screw, t, usage, sf.Name, field); b {
+				c = newCommand
+				//The subcommand has its own namespace
+				group = optionGroup{}
 			}
 		}
+
+		var err error
+		if group, err = group.child(sf); err != nil {
+			return err
+		}
 	}
 
-	if v.Kind() != reflect.Struct {
+	if !isStruct {
 
 		def := Tag(sf.Tag).Get("default")
 		def = strings.TrimSpace(def)
+		sep := Tag(sf.Tag).Get("sep")
+		layout := Tag(sf.Tag).Get("layout")
+		if err := checkLayout(layout, t); err != nil {
+			return err
+		}
+
 		if len(def) > 0 {
-			if err := setDefaultValue(def, v); err != nil {
+			//Check the default value once here, it is set again on every binding
+			if err := setDefaultValue(def, sep, layout, reflect.New(t).Elem()); err != nil {
 				return err
 			}
+			root := c.getRoot()
+			root.defaults = append(root.defaults, defaultValue{field: field, value: def, sep: sep, layout: layout})
 		}
 
 		if len(screw) == 0 && len(usage) == 0 {
@@ -901,13 +1209,29 @@ func (c *Screw) registerCore(v reflect.Value, sf reflect.StructField) error {
 			}
 		}
 
-		return c.parseTagAndSetOption(screw, usage, def, sf.Name, v)
+		root := c.getRoot()
+		option := &Option{usage: usage, typ: t, field: field, id: root.numOptions, showDefValue: def, sep: sep, layout: layout, prefix: group.prefix,
+			prompt: Tag(sf.Tag).Get("prompt"), required: isRequired(Tag(sf.Tag).Get("valid")), secret: t == secretType}
+		root.numOptions++
+		if err := option.parseChoices(Tag(sf.Tag).Get("choices")); err != nil {
+			return err
+		}
+
+		if err := c.parseTagAndSetOption(screw, sf.Name, owner, option, group); err != nil {
+			return err
+		}
+
+		//The default value must be one of the choices too
+		if len(def) > 0 {
+			if _, err := option.checkChoices(def, sep); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
 
-	typ := v.Type()
-	c.structAddr = v.Addr()
-	for i := 0; i < v.NumField(); i++ {
-		sf := typ.Field(i)
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
 
 		if sf.PkgPath != "" && !sf.Anonymous {
 			continue
@@ -915,7 +1239,7 @@ func (c *Screw) registerCore(v reflect.Value, sf reflect.StructField) error {
 
 		//fmt.Printf("my.index(%d)(1.%s)-->(2.%s)\n", i, Tag(sf.Tag).Get("screw"), Tag(sf.Tag).Get("usage"))
 		//fmt.Printf("stdlib.index(%d)(1.%s)-->(2.%s)\n", i, sf.Tag.Get("screw"), sf.Tag.Get("usage"))
-		if err := c.registerCore(v.Field(i), sf); err != nil {
+		if err := c.registerCore(sf.Type, sf, t, field.child(i), group); err != nil {
 			return err
 		}
 	}
@@ -925,6 +1249,13 @@ func (c *Screw) registerCore(v reflect.Value, sf reflect.StructField) error {
 
 var emptyField = reflect.StructField{}
 
+// Register the structure type, the options keep the location of their fields instead of the values
+func (c *command) register(t reflect.Type) error {
+	field := fieldPath{root: len(c.types)}
+	c.types = append(c.types, t)
+	return c.registerCore(t, emptyField, nil, field, optionGroup{})
+}
+
 func (c *Screw) register(x interface{}) error {
 	if x == nil {
 		return ErrUnsupportedType
@@ -942,11 +1273,11 @@ func (c *Screw) register(x interface{}) error {
 		return ErrUnsupportedType
 	}
 
-	c.allStruct[x] = struct{}{}
-	return c.registerCore(v, emptyField)
+	c.structs = append(c.structs, v)
+	return c.command.register(v.Type())
 }
 
-func (c *Screw) parseOneOption(index *int) error {
+func (c *cmdParser) parseOneOption(index *int) error {
 
 	arg := c.args[*index]
 
@@ -955,29 +1286,38 @@ func (c *Screw) parseOneOption(index *int) error {
 	}
 
 	if arg[0] != '-' {
-		if len(c.subcommand) > 0 {
-			newScrew, ok := c.subcommand[arg]
-			//The subcommands and args do not start with a - sign. If env or args are not set,
-			//they are regarded as unregistered subcommands, and an error is directly reported
-			if !ok && len(c.envAndArgs) == 0 {
-				return fmt.Errorf("Unknown subcommand:%s", arg)
-			}
-
-			c.getRoot().isSetSubcommand[arg] = struct{}{}
-			if c.root == nil {
-				c.currSubcommandFieldName = newScrew.fieldName
+		if newCommand, ok := c.subcommand[arg]; ok {
+			c.isSetSubcommand[arg] = struct{}{}
+			if c.parent == nil {
+				c.currSubcommand = newCommand.command
 			}
 
-			newScrew.args = c.args[*index+1:]
+			sub := c.newParser(newCommand.command, c.args[*index+1:], c.base+*index+1)
 			c.args = c.args[0:0]
-			err := newScrew.bindStruct()
-			if err != nil {
+			if err := sub.bindStruct(); err != nil {
 				return err
 			}
-			if newScrew.subMain.IsValid() {
-				newScrew.subMain.Call([]reflect.Value{})
+			if newCommand.subMain {
+				c.fieldValue(newCommand.field).Addr().MethodByName(defaultSubMain).Call([]reflect.Value{})
 			}
+			return nil
 		}
+
+		//The subcommands and args do not start with a - sign. If args are not set,
+		//they are regarded as unregistered subcommands, which run a plugin or report an error
+		if len(c.checkArgs) == 0 {
+			if c.getRoot().plugins {
+				if path, ok := c.findPlugin(arg, c.lookupEnv); ok {
+					args := c.args[*index+1:]
+					c.args = c.args[0:0]
+					return c.runPlugin(arg, path, args)
+				}
+			}
+			if len(c.subcommand) > 0 {
+				return fmt.Errorf("Unknown subcommand:%s", arg)
+			}
+		}
+
 		c.unparsedArgs = append(c.unparsedArgs, unparsedArg{arg: arg, index: *index})
 		return nil
 	}
@@ -999,23 +1339,39 @@ func (c *Screw) parseOneOption(index *int) error {
 }
 
 // Set environment variables
-func (c *Screw) bindEnvAndArgs() error {
+func (c *cmdParser) bindEnvAndArgs() error {
+	var last *optionState
 	for _, o := range c.envAndArgs {
-		if err := o.setEnvAndArgs(c); err != nil {
+		option := c.state(o)
+		if err := option.setEnvAndArgs(c); err != nil {
 			return err
 		}
+		if len(o.argsName) > 0 {
+			last = option
+		}
 	}
 
+	//The arguments left after the last positional array overflow it
+	if last != nil && last.kind() == reflect.Array && len(c.unparsedArgs) > 0 {
+		err := fmt.Errorf("error: %s takes %d values, but more were provided", last.showName(), last.typ.Len())
+		return c.argError(c.unparsedArgs[0].index, err)
+	}
 	return nil
 }
 
 // Bind structure
-func (c *Screw) bindStruct() error {
+func (c *cmdParser) bindStruct() error {
+	//The response files are expanded once, before any option or subcommand is dispatched
+	if c.responseFiles && c.parent == nil {
+		if err := c.expandResponseFiles(); err != nil {
+			return err
+		}
+	}
 
 	for i := 0; i < len(c.args); i++ {
 
 		if err := c.parseOneOption(&i); err != nil {
-			return err
+			return c.argError(i, err)
 		}
 
 	}
@@ -1030,7 +1386,9 @@ func (c *Screw) Bind(x interface{}) (err error) {
 	}
 
 	defer func() {
-		if err != nil {
+		//The plugin has reported its own errors
+		var pluginExit *PluginExit
+		if err != nil && !errors.As(err, &pluginExit) {
 			fmt.Fprintln(c.w, err)
 			fmt.Fprintln(c.w, "For more information try --help")
 			if c.exit {
@@ -1043,35 +1401,36 @@ func (c *Screw) Bind(x interface{}) (err error) {
 		return err
 	}
 
-	if err = c.bindStruct(); err != nil {
-		return err
-	}
-
-	if len(c.currSubcommandFieldName) > 0 {
-		v := reflect.ValueOf(x)
-		v = v.Elem() // can only be a pointer, which has been judged in c.register
-		v = v.FieldByName(c.currSubcommandFieldName)
-		//Only the set subcommands need data verification
-		//Delete the root structure here
-		delete(c.allStruct, x)
-		x = v.Addr().Interface()
-	}
-
-	c.allStruct[x] = struct{}{}
-
-	for x := range c.allStruct {
-		err = valid.ValidateStruct(x)
-		if err != nil {
-			errs := err.(validator.ValidationErrors)
-
-			for _, e := range errs {
-				// can translate each error one at a time.
-				return errors.New(e.Translate(valid.trans))
-			}
+	c.binder = newBinder(c.command, c.args)
+	c.binder.offsets = c.offsets
+	c.binder.responseFiles = c.responseFiles
+	c.binder.responseLines = c.responseLines
+	c.binder.prompter = c.prompter
+	c.binder.terminal = true
+	c.binder.lookupEnv = c.lookupEnv
+	c.binder.environ = c.environ
+	c.binder.exit = c.exit
+	c.binder.w = c.w
+	return c.binder.bind(c.structs)
+}
 
-		}
-	}
-	return err
+// A new Screw parsing args, the settings of c are kept and the registered structures are not
+func (c *Screw) reset(args []string) *Screw {
+	n := New(args)
+	n.responseFiles = c.responseFiles
+	n.responseLines = c.responseLines
+	n.exit = c.exit
+	n.w = c.w
+	n.prompter = c.prompter
+	n.lookupEnv = c.lookupEnv
+	n.environ = c.environ
+
+	n.envPrefix = c.envPrefix
+	n.autoEnv = c.autoEnv
+	n.printConfig = c.printConfig
+	n.plugins = c.plugins
+	n.pluginDirs = c.pluginDirs
+	return n
 }
 
 // MustBind is similar to Bind function, and the error is direct panic
@@ -1102,7 +1461,7 @@ func MustRegister(x interface{}) {
 // Structure field registration
 // Command line parsing
 func Bind(x interface{}, version string, about string) error {
-	CommandLine = New(os.Args[1:])
+	CommandLine = CommandLine.reset(os.Args[1:])
 	CommandLine.SetAbout(about)
 	CommandLine.SetVersion(version)
 	CommandLine.SetProcName(os.Args[0])
@@ -1117,6 +1476,18 @@ func SetAbout(about string) {
 	CommandLine.SetAbout(about)
 }
 
+func SetEnvPrefix(prefix string) {
+	CommandLine.SetEnvPrefix(prefix)
+}
+
+func SetEnv(lookup func(string) (string, bool)) {
+	CommandLine.SetEnv(lookup)
+}
+
+func SetAutoEnv(auto bool) {
+	CommandLine.SetAutoEnv(auto)
+}
+
 // Bind must be a successful version
 func MustBind(x interface{}) {
 	CommandLine.SetProcName(os.Args[0])
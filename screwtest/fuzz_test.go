@@ -0,0 +1,29 @@
+//go:build go1.18
+// +build go1.18
+
+package screwtest
+
+import (
+	"strings"
+	"testing"
+)
+
+// An example fuzz target, run it with go test -fuzz=FuzzArgs ./screwtest
+func FuzzArgs(f *testing.F) {
+	for _, args := range [][]string{
+		{"-n", "x", "--user", "u"},
+		{"--user=u", "serve", "-p", "81"},
+		{"-n"},
+		{"--panic", "ok", "--", "-x"},
+	} {
+		f.Add([]byte(strings.Join(args, "\x00")))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		//The callback panics on purpose
+		if strings.Contains(string(data), "boom") {
+			t.Skip()
+		}
+		Fuzz(t, func() interface{} { return new(config) }, data)
+	})
+}
@@ -0,0 +1,175 @@
+// Package screwtest runs screw based command lines in tests, without exiting the process,
+// writing to os.Stdout or reading the real environment
+package screwtest
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/RainFallsSilent/screw"
+)
+
+var update = flag.Bool("screwtest.update", false, "rewrite the golden files with the output")
+
+// Harness holds the arguments, the env and the prompt answers of one run
+type Harness struct {
+	args    []string
+	env     map[string]string
+	prompts map[string]string
+	setup   []func(*screw.Screw)
+}
+
+// Result is the outcome of one run
+type Result struct {
+	//The help, version and error messages written by screw
+	Output string
+	Err    error
+	//The Screw that bound the structure, such as for Source and Occurrences
+	Screw *screw.Screw
+}
+
+func New(args ...string) *Harness {
+	return &Harness{args: args, env: map[string]string{}, prompts: map[string]string{}}
+}
+
+// Set the env, the real environment is never read
+func (h *Harness) Env(env map[string]string) *Harness {
+	for k, v := range env {
+		h.env[k] = v
+	}
+	return h
+}
+
+// Set the answer of the prompt, the prompts without an answer fail the run
+func (h *Harness) Prompt(message string, answer string) *Harness {
+	h.prompts[message] = answer
+	return h
+}
+
+// Configure the Screw before binding, such as SetEnvPrefix or SetVersion
+func (h *Harness) Setup(fn func(*screw.Screw)) *Harness {
+	h.setup = append(h.setup, fn)
+	return h
+}
+
+// Bind the args into x, it is a pointer to a structure
+func (h *Harness) Run(x interface{}) *Result {
+	var out bytes.Buffer
+	s := h.newScrew(&out)
+	err := s.Bind(x)
+	return &Result{Output: out.String(), Err: err, Screw: s}
+}
+
+// Print the help message of x without binding the args
+func (h *Harness) Help(x interface{}) *Result {
+	var out bytes.Buffer
+	s := h.newScrew(&out)
+	if err := s.Register(x); err != nil {
+		return &Result{Err: err, Screw: s}
+	}
+
+	s.Usage()
+	return &Result{Output: out.String(), Screw: s}
+}
+
+func (h *Harness) newScrew(out io.Writer) *screw.Screw {
+	s := screw.New(h.args).SetExit(false).SetOutput(out)
+	s.SetEnv(func(name string) (string, bool) {
+		v, ok := h.env[name]
+		return v, ok
+	})
+	s.SetPrompter(screw.PromptFunc(func(message string, secret bool) (string, error) {
+		if v, ok := h.prompts[message]; ok {
+			return v, nil
+		}
+		return "", fmt.Errorf("screwtest: no answer for the prompt %q", message)
+	}))
+
+	for _, fn := range h.setup {
+		fn(s)
+	}
+	return s
+}
+
+// Fail the test if the run failed
+func (r *Result) NoError(t testing.TB) *Result {
+	t.Helper()
+	if r.Err != nil {
+		t.Fatalf("screwtest: unexpected error: %v\n%s", r.Err, r.Output)
+	}
+	return r
+}
+
+// Fail the test if the run did not fail with an error containing msg
+func (r *Result) ErrorContains(t testing.TB, msg string) *Result {
+	t.Helper()
+	if r.Err == nil || !strings.Contains(r.Err.Error(), msg) {
+		t.Fatalf("screwtest: want an error containing %q, got %v", msg, r.Err)
+	}
+	return r
+}
+
+// Compare the output with the golden file, run the tests with -screwtest.update to rewrite it
+func (r *Result) Golden(t testing.TB, path string) *Result {
+	t.Helper()
+	AssertGolden(t, path, r.Output)
+	return r
+}
+
+// Compare got with the golden file, run the tests with -screwtest.update to rewrite it
+func AssertGolden(t testing.TB, path string, got string) {
+	t.Helper()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("screwtest: %v, run the tests with -screwtest.update to create it", err)
+	}
+
+	if string(want) != got {
+		t.Fatalf("screwtest: the output does not match %s\n--- want\n%s\n--- got\n%s", path, want, got)
+	}
+}
+
+// Compare the help message of x with the golden file
+func AssertHelp(t testing.TB, x interface{}, path string, setup ...func(*screw.Screw)) {
+	t.Helper()
+	h := New()
+	for _, fn := range setup {
+		h.Setup(fn)
+	}
+	h.Help(x).NoError(t).Golden(t, path)
+}
+
+// Fuzz binds the arguments split from data by NUL bytes into a new structure, a panic fails the test.
+// Call it from a fuzz target, such as
+//
+//	f.Fuzz(func(t *testing.T, data []byte) {
+//		screwtest.Fuzz(t, func() interface{} { return new(Config) }, data)
+//	})
+func Fuzz(t testing.TB, newX func() interface{}, data []byte) {
+	t.Helper()
+	args := strings.Split(string(data), "\x00")
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("screwtest: panic with the args %q: %v", args, r)
+		}
+	}()
+
+	New(args...).Run(newX())
+}
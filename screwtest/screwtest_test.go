@@ -0,0 +1,195 @@
+package screwtest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/RainFallsSilent/screw"
+)
+
+type serve struct {
+	Port int `screw:"-p;--port;env=PORT" default:"8080" usage:"port"`
+}
+
+type config struct {
+	Name  string `screw:"-n;--name" usage:"name"`
+	User  string `screw:"--user" prompt:"User" valid:"required" usage:"user"`
+	Panic string `screw:"--panic;callback=Boom" usage:"panics with boom"`
+	Serve serve  `screw:"subcommand=serve" usage:"serve"`
+}
+
+func (c *config) Boom(val string) {
+	if val == "boom" {
+		panic("boom")
+	}
+	c.Panic = val
+}
+
+// fakeTB records the failure of the helpers, Fatal stops the goroutine like testing.T
+type fakeTB struct {
+	testing.TB
+	failed bool
+	msg    string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Fatal(args ...interface{}) {
+	f.Fatalf("%s", fmt.Sprint(args...))
+}
+
+func (f *fakeTB) Fatalf(format string, args ...interface{}) {
+	f.failed = true
+	f.msg = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+// Run fn with a fakeTB in a new goroutine, so Goexit only stops fn
+func runFake(t *testing.T, fn func(tb testing.TB)) *fakeTB {
+	f := &fakeTB{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(f)
+	}()
+	<-done
+	return f
+}
+
+func Test_Run(t *testing.T) {
+	for _, test := range []struct {
+		h    *Harness
+		need config
+		port string
+	}{
+		{
+			h:    New("-n", "x").Prompt("User", "bob"),
+			need: config{Name: "x", User: "bob", Serve: serve{Port: 8080}},
+		},
+		{
+			h:    New("--user", "u", "serve").Env(map[string]string{"PORT": "81"}),
+			need: config{User: "u", Serve: serve{Port: 81}},
+			port: "env PORT",
+		},
+		{
+			//The env of the process is never read
+			h:    New("--user", "u", "serve"),
+			need: config{User: "u", Serve: serve{Port: 8080}},
+			port: "default",
+		},
+	} {
+		os.Setenv("PORT", "82")
+		var got config
+		r := test.h.Run(&got).NoError(t)
+		os.Unsetenv("PORT")
+
+		if got != test.need {
+			t.Errorf("got %+v, need %+v", got, test.need)
+		}
+		if src := r.Screw.Source("Serve.Port").String(); len(test.port) > 0 && src != test.port {
+			t.Errorf("got %s, need %s", src, test.port)
+		}
+	}
+}
+
+func Test_Run_Error(t *testing.T) {
+	New("--user", "u", "serve", "-p", "x").Run(new(config)).ErrorContains(t, "invalid syntax")
+	New().Run(new(config)).ErrorContains(t, `no answer for the prompt "User"`)
+
+	r := New("-x", "--user", "u").Run(new(config)).ErrorContains(t, "-x")
+	if !strings.Contains(r.Output, "For more information try --help") {
+		t.Errorf("the output is not captured: %q", r.Output)
+	}
+
+	r = New("--version", "--user", "u").Setup(func(s *screw.Screw) { s.SetVersion("v9") }).Run(new(config)).NoError(t)
+	if !strings.Contains(r.Output, "v9") {
+		t.Errorf("got %q", r.Output)
+	}
+}
+
+// The helpers fail the test
+func Test_Result_Fail(t *testing.T) {
+	for _, test := range []struct {
+		fn  func(tb testing.TB)
+		msg string
+	}{
+		{
+			fn:  func(tb testing.TB) { New("--unknown").Run(new(config)).NoError(tb) },
+			msg: "screwtest: unexpected error",
+		},
+		{
+			fn:  func(tb testing.TB) { New("--user", "u").Run(new(config)).ErrorContains(tb, "x") },
+			msg: `want an error containing "x", got <nil>`,
+		},
+		{
+			fn:  func(tb testing.TB) { New("--unknown").Run(new(config)).ErrorContains(tb, "zzz") },
+			msg: `want an error containing "zzz", got error: Found argument '--unknown'`,
+		},
+	} {
+		if f := runFake(t, test.fn); !f.failed || !strings.Contains(f.msg, test.msg) {
+			t.Errorf("got %t %q, need %q", f.failed, f.msg, test.msg)
+		}
+	}
+}
+
+func Test_AssertGolden(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "testdata", "out.golden")
+
+	//The missing file asks for the update
+	f := runFake(t, func(tb testing.TB) { AssertGolden(tb, path, "a\n") })
+	if !f.failed || !strings.Contains(f.msg, "-screwtest.update") {
+		t.Errorf("got %t %q", f.failed, f.msg)
+	}
+
+	//The update creates the dirs and the file
+	*update = true
+	f = runFake(t, func(tb testing.TB) { AssertGolden(tb, path, "a\n") })
+	*update = false
+	if f.failed {
+		t.Fatal(f.msg)
+	}
+	if data, err := ioutil.ReadFile(path); err != nil || string(data) != "a\n" {
+		t.Fatalf("got %q %v", data, err)
+	}
+
+	AssertGolden(t, path, "a\n")
+
+	f = runFake(t, func(tb testing.TB) { AssertGolden(tb, path, "b\n") })
+	if !f.failed || !strings.Contains(f.msg, "--- want\na\n\n--- got\nb\n") {
+		t.Errorf("got %t %q", f.failed, f.msg)
+	}
+}
+
+func Test_AssertHelp(t *testing.T) {
+	AssertHelp(t, new(config), "testdata/help.golden", func(s *screw.Screw) {
+		s.SetProcName("app")
+	})
+
+	//The real env is not shown in the help
+	os.Setenv("PORT", "82")
+	defer os.Unsetenv("PORT")
+	r := New().Help(new(serve)).NoError(t)
+	if strings.Contains(r.Output, "82") {
+		t.Errorf("the env of the process is shown:\n%s", r.Output)
+	}
+}
+
+func Test_Fuzz(t *testing.T) {
+	newX := func() interface{} { return new(config) }
+	for _, data := range []string{"", "-n\x00x", "--user\x00u\x00serve\x00-p\x00x", "--panic\x00ok", "\x00\x00-"} {
+		if f := runFake(t, func(tb testing.TB) { Fuzz(tb, newX, []byte(data)) }); f.failed {
+			t.Errorf("%q: %s", data, f.msg)
+		}
+	}
+
+	f := runFake(t, func(tb testing.TB) { Fuzz(tb, newX, []byte("--panic\x00boom")) })
+	if !f.failed || !strings.Contains(f.msg, `screwtest: panic with the args ["--panic" "boom"]: boom`) {
+		t.Errorf("got %t %q", f.failed, f.msg)
+	}
+}
@@ -0,0 +1,100 @@
+package screw
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// The text shown instead of a secret value
+const redacted = "******"
+
+var secretType = reflect.TypeOf(Secret(""))
+
+// Secret is a string that is redacted when it is printed, the options of this type are secret options.
+// Use Value to get the string
+type Secret string
+
+func (s Secret) Value() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	if len(s) == 0 {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) GoString() string {
+	return strconv.Quote(s.String())
+}
+
+// The JSON and text encodings are redacted too
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// The value of the secret options can be read from a file with @/path
+func readSecret(val string) (string, error) {
+	if !strings.HasPrefix(val, "@") {
+		return val, nil
+	}
+
+	data, err := ioutil.ReadFile(val[1:])
+	if err != nil {
+		return "", fmt.Errorf("error: read the secret file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// Set the value of the command line, the value of the secret options is read from the file of @/path.
+// The env, prompted and configuration values are used as they are
+func setArgValue(val string, sep string, option *optionState, index int, lowIndex int) (err error) {
+	if option.secret {
+		if val, err = readSecret(val); err != nil {
+			return err
+		}
+	}
+	return setValueAndIndex(val, sep, option, index, lowIndex)
+}
+
+// Remove the secret value and its elements from the error message. Only the quoted values are replaced,
+// such as "x", 'x' or (x), so a short secret does not change the other words of the message
+func redactError(err error, val string, sep string) error {
+	if err == nil || len(val) == 0 {
+		return err
+	}
+
+	parts := []string{val}
+	if len(sep) > 0 {
+		parts = append(parts, strings.Split(val, sep)...)
+	}
+
+	var pairs []string
+	for _, p := range parts {
+		if len(p) > 0 {
+			pairs = append(pairs, strconv.Quote(p), strconv.Quote(redacted), "'"+p+"'", "'"+redacted+"'", "("+p+")", "("+redacted+")")
+		}
+	}
+	return errors.New(strings.NewReplacer(pairs...).Replace(err.Error()))
+}
+
+// The value of --token-file is the file of --token
+func (o *Option) fileValue(name string, val string) string {
+	if len(o.fileName) > 0 && name == o.fileName {
+		return "@" + val
+	}
+	return val
+}
+
+// The text shown instead of the value
+func (o *Option) redact(val string) string {
+	if o.secret && len(val) > 0 {
+		return redacted
+	}
+	return val
+}
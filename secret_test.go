@@ -0,0 +1,152 @@
+package screw
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type secretConfig struct {
+	Debug bool     `screw:"-d" usage:"debug"`
+	Token string   `screw:"-t;--token;secret;env=TOKEN" usage:"api token"`
+	Key   Secret   `screw:"--key" usage:"signing key"`
+	Pin   int      `screw:"--pin;secret" default:"1234" usage:"pin"`
+	Files []string `screw:"args=files" usage:"files"`
+}
+
+func Test_Secret(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"tok": "sup3r secret\n", "key": "k"})
+	tok, key := filepath.Join(dir, "tok"), filepath.Join(dir, "key")
+
+	for _, test := range []struct {
+		args []string
+		env  []string
+		need secretConfig
+	}{
+		{args: []string{"--token", "x"}, need: secretConfig{Token: "x", Pin: 1234}},
+		//The files of the secrets are not response files
+		{args: []string{"--token", "@" + tok, "a"}, need: secretConfig{Token: "sup3r secret", Pin: 1234, Files: []string{"a"}}},
+		{args: []string{"-t", "@" + tok}, need: secretConfig{Token: "sup3r secret", Pin: 1234}},
+		{args: []string{"-dt", "@" + tok}, need: secretConfig{Debug: true, Token: "sup3r secret", Pin: 1234}},
+		{args: []string{"--token=@" + tok}, need: secretConfig{Token: "sup3r secret", Pin: 1234}},
+		{args: []string{"--token-file", tok}, need: secretConfig{Token: "sup3r secret", Pin: 1234}},
+		{args: []string{"--key", "@" + key}, need: secretConfig{Key: "k", Pin: 1234}},
+		//Only the command line values are read from files
+		{args: nil, env: []string{"TOKEN=@" + tok}, need: secretConfig{Token: "@" + tok, Pin: 1234}},
+	} {
+		var got secretConfig
+		spec := MustCompile((*secretConfig)(nil))
+		if err := spec.NewBinder(test.args).SetResponseFiles(true).SetEnviron(test.env).Bind(&got); err != nil {
+			t.Fatalf("%q: %v", test.args, err)
+		}
+
+		if got.Debug != test.need.Debug || got.Token != test.need.Token || got.Key != test.need.Key || got.Pin != test.need.Pin ||
+			strings.Join(got.Files, " ") != strings.Join(test.need.Files, " ") {
+			t.Errorf("%q %q: got %+v, need %+v", test.args, test.env, got, test.need)
+		}
+	}
+}
+
+// The values typed in and the values of a document are not files
+func Test_Secret_NotFiles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"tok": "sup3r"})
+	tok := filepath.Join(dir, "tok")
+
+	var got secretConfig
+	p := PromptFunc(func(message string, secret bool) (string, error) { return "@" + tok, nil })
+	type config struct {
+		Token string `screw:"--token;secret" prompt:"Token" valid:"required"`
+	}
+	var c config
+	if err := New(nil).SetExit(false).SetPrompter(p).Bind(&c); err != nil || c.Token != "@"+tok {
+		t.Errorf("got %q %v", c.Token, err)
+	}
+
+	if err := New(nil).SetExit(false).BindMap(map[string]interface{}{"token": "@" + tok}, &got); err != nil || got.Token != "@"+tok {
+		t.Errorf("got %q %v", got.Token, err)
+	}
+
+	got = secretConfig{}
+	if err := New(nil).SetExit(false).BindMap(map[string]interface{}{"token-file": tok}, &got); err != nil || got.Token != "sup3r" {
+		t.Errorf("got %q %v", got.Token, err)
+	}
+}
+
+func Test_Secret_Redact(t *testing.T) {
+	var w bytes.Buffer
+	var got secretConfig
+	s := New([]string{"--pin", "98x", "--token", "hunter2"}).SetExit(false).SetOutput(&w)
+	err := s.Bind(&got)
+	if err == nil || strings.Contains(err.Error(), "98x") || strings.Contains(w.String(), "98x") {
+		t.Errorf("the value is not redacted: %v\n%s", err, w.String())
+	}
+
+	w.Reset()
+	s = New([]string{"--token", "hunter2", "-t", "hunter3"}).SetExit(false).SetOutput(&w).SetPrintConfig(true)
+	if err := s.Bind(&got); err != nil {
+		t.Fatal(err)
+	}
+	s.Usage()
+	s.PrintConfig(&w)
+	for _, o := range s.Occurrences() {
+		w.WriteString(o.Arg + o.Value + "\n")
+	}
+	w.WriteString(s.Source("Token").Raw)
+
+	out := w.String()
+	for _, v := range []string{"hunter2", "hunter3", "1234"} {
+		if strings.Contains(out, v) {
+			t.Errorf("%s is shown:\n%s", v, out)
+		}
+	}
+}
+
+// Only the quoted value is redacted, the other words of the message are kept
+func Test_Secret_RedactShort(t *testing.T) {
+	var got secretConfig
+	err := New([]string{"--pin", "a"}).SetExit(false).SetOutput(ioutil.Discard).Bind(&got)
+	if err == nil || err.Error() != `strconv.ParseInt: parsing "******": invalid syntax` {
+		t.Errorf("got %v", err)
+	}
+}
+
+func Test_Secret_Error(t *testing.T) {
+	var got secretConfig
+	err := New([]string{"--token", "@/nonexistent/tok"}).SetExit(false).SetOutput(ioutil.Discard).Bind(&got)
+	if err == nil || !strings.Contains(err.Error(), "read the secret file") {
+		t.Errorf("got %v", err)
+	}
+}
+
+// The error of a repeated once option names the option, the values are not shown
+func Test_Secret_Once(t *testing.T) {
+	type config struct {
+		Debug bool   `screw:"-d" usage:"debug"`
+		Token string `screw:"-t;--token;secret;once" usage:"api token"`
+	}
+
+	for _, test := range []struct {
+		args []string
+		name string
+	}{
+		{args: []string{"-ts3cr3t", "-t", "0th3r"}, name: "'-t'"},
+		{args: []string{"-t", "s3cr3t", "-dt=0th3r"}, name: "'-t'"},
+		{args: []string{"--token=s3cr3t", "--token=0th3r"}, name: "'--token'"},
+		{args: []string{"--token", "s3cr3t", "--token", "0th3r"}, name: "'--token'"},
+	} {
+		var out bytes.Buffer
+		err := New(test.args).SetExit(false).SetOutput(&out).Bind(new(config))
+		if err == nil || !strings.Contains(err.Error(), "The argument "+test.name+" was provided more than once") {
+			t.Errorf("%q: got %v", test.args, err)
+			continue
+		}
+
+		for _, v := range []string{"s3cr3t", "0th3r"} {
+			if strings.Contains(err.Error(), v) || strings.Contains(out.String(), v) {
+				t.Errorf("%q: %s is shown: %v\n%s", test.args, v, err, out.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package screw
+
+import (
+	"reflect"
+	"testing"
+)
+
+type sepConfig struct {
+	Hosts  []string          `screw:"--hosts;env=HOSTS" usage:"hosts"`
+	Ports  []int             `screw:"--ports;greedy" sep:":" default:"80:443" usage:"ports"`
+	Labels map[string]string `screw:"--labels;env=LABELS" usage:"labels"`
+	Paths  []string          `screw:"--path;env=PATHS" sep:";" usage:"paths"`
+	Debug  []bool            `screw:"env=DEBUG" usage:"debug"`
+}
+
+func Test_Sep(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		env  []string
+		need sepConfig
+	}{
+		{
+			need: sepConfig{Ports: []int{80, 443}},
+		},
+		{
+			args: []string{"--ports", "8080:8443", "9090"},
+			need: sepConfig{Ports: []int{8080, 8443, 9090}},
+		},
+		{
+			//The env of slices is split by comma without the sep tag
+			env:  []string{"HOSTS=a,b,c", "LABELS=env=prod,team=core", "PATHS=/a,b;/c", "DEBUG=true,false"},
+			need: sepConfig{Hosts: []string{"a", "b", "c"}, Ports: []int{80, 443}, Labels: map[string]string{"env": "prod", "team": "core"}, Paths: []string{"/a,b", "/c"}, Debug: []bool{true, false}},
+		},
+		{
+			env:  []string{`LABELS={"a,b":"c=d"}`},
+			need: sepConfig{Ports: []int{80, 443}, Labels: map[string]string{"a,b": "c=d"}},
+		},
+		{
+			//The option value of a slice is split by sep only, the pairs of a map also by comma
+			args: []string{"--hosts", "a,b", "--path", "/x;/y", "--labels", "k=v,k2=v2"},
+			need: sepConfig{Hosts: []string{"a,b"}, Ports: []int{80, 443}, Labels: map[string]string{"k": "v", "k2": "v2"}, Paths: []string{"/x", "/y"}},
+		},
+		{
+			//A part without = belongs to the value of the previous pair
+			args: []string{"--labels", "k=a,b", "--labels", "x=1,y=2,3"},
+			need: sepConfig{Ports: []int{80, 443}, Labels: map[string]string{"k": "a,b", "x": "1", "y": "2,3"}},
+		},
+		{
+			//The env replaces the values of the command line, they are not merged
+			args: []string{"--hosts", "a,b", "--path", "/x;/y", "--labels", "k=v,k2=v2"},
+			env:  []string{"HOSTS=c", "LABELS=a=b"},
+			need: sepConfig{Hosts: []string{"c"}, Ports: []int{80, 443}, Labels: map[string]string{"a": "b"}, Paths: []string{"/x", "/y"}},
+		},
+	} {
+		var got sepConfig
+		spec := MustCompile((*sepConfig)(nil))
+		if err := spec.NewBinder(test.args).SetEnviron(test.env).Bind(&got); err != nil {
+			t.Fatalf("%q %q: %v", test.args, test.env, err)
+		}
+
+		if !reflect.DeepEqual(got, test.need) {
+			t.Errorf("%q %q: got %+v, need %+v", test.args, test.env, got, test.need)
+		}
+	}
+}
+
+func Test_Sep_Error(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		env  []string
+	}{
+		{args: []string{"--ports", "1:x"}},
+		{env: []string{"LABELS=novalue"}},
+		{env: []string{"DEBUG=true,maybe"}},
+	} {
+		spec := MustCompile((*sepConfig)(nil))
+		if err := spec.NewBinder(test.args).SetEnviron(test.env).Bind(new(sepConfig)); err == nil {
+			t.Errorf("%q %q: no error", test.args, test.env)
+		}
+	}
+
+	type badDefault struct {
+		Ports []int `screw:"--ports" sep:":" default:"80,443" usage:"ports"`
+	}
+	if _, err := Compile((*badDefault)(nil)); err == nil {
+		t.Error("no error for the default value split by the wrong separator")
+	}
+}
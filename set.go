@@ -1,10 +1,12 @@
 package screw
 
 import (
+	"encoding"
 	"encoding/json"
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -90,12 +92,12 @@ func setFloatField(val string, bitSize int, field reflect.Value) error {
 	return err
 }
 
+// time.Time parses itself with UnmarshalText, other structures are JSON
 func setStructField(val string, bitSize int, value reflect.Value) error {
-	switch value.Interface().(type) {
-	case time.Time:
-		//return setTimeField(val, bitSize, value)
+	if strings.HasPrefix(strings.TrimSpace(val), "{") {
+		return json.Unmarshal([]byte(val), value.Addr().Interface())
 	}
-	return json.Unmarshal([]byte(val), value.Addr().Interface())
+	return setStructPairs(val, defaultSep, value)
 }
 
 func setSlice(val string, bitSize int, value reflect.Value) error {
@@ -121,8 +123,99 @@ func setSlice(val string, bitSize int, value reflect.Value) error {
 	return nil
 }
 
+// mapPairs splits the k=v pairs of a map value. Without sep, the value is split by comma,
+// and a part without = belongs to the value of the previous pair, so x=1,y=2 is two pairs
+// and k=a,b is one pair with the value a,b
+func mapPairs(val string, sep string) []string {
+	if len(sep) > 0 {
+		return strings.Split(val, sep)
+	}
+
+	var pairs []string
+	for _, part := range strings.Split(val, defaultSep) {
+		if len(pairs) > 0 && strings.IndexByte(part, '=') == -1 {
+			pairs[len(pairs)-1] += defaultSep + part
+			continue
+		}
+		pairs = append(pairs, part)
+	}
+	return pairs
+}
+
 func setMapField(val string, bitSize int, value reflect.Value) error {
-	return json.Unmarshal([]byte(val), value.Addr().Interface())
+	return setMapPairs(val, "", value, false)
+}
+
+// The map value is a JSON object or k=v pairs split by sep, such as k=v,k2=v2.
+// Without sep, the pairs are split by comma, see mapPairs.
+// The pairs are merged into the map, if once is set, a key can not be set twice
+func setMapPairs(val string, sep string, value reflect.Value, once bool) error {
+	if strings.HasPrefix(strings.TrimSpace(val), "{") {
+		return json.Unmarshal([]byte(val), value.Addr().Interface())
+	}
+
+	pairs := mapPairs(val, sep)
+	if value.IsNil() {
+		value.Set(reflect.MakeMap(value.Type()))
+	}
+
+	for _, pair := range pairs {
+		if len(pair) == 0 {
+			continue
+		}
+
+		pos := strings.IndexByte(pair, '=')
+		if pos == -1 {
+			return fmt.Errorf("invalid map value (%s), want key=value", pair)
+		}
+
+		k := reflect.New(value.Type().Key()).Elem()
+		if err := setBase(pair[:pos], k); err != nil {
+			return err
+		}
+
+		if once && value.MapIndex(k).IsValid() {
+			return fmt.Errorf(`error: The key '%s' was provided more than once, but cannot be used multiple times`, pair[:pos])
+		}
+
+		v := reflect.New(value.Type().Elem()).Elem()
+		if err := setBase(pair[pos+1:], v); err != nil {
+			return err
+		}
+
+		value.SetMapIndex(k, v)
+	}
+
+	return nil
+}
+
+// Set the value that holds several elements split by sep, such as a,b,c for slices.
+// Without sep, one value is one element of the slice
+func setSepValue(val string, sep string, value reflect.Value) error {
+	switch value.Kind() {
+	case reflect.Slice:
+		//The elements holding several values take one value each, such as [][]string and []struct
+		if isMultiValue(value.Type().Elem()) {
+			return appendMultiValue(val, sep, value)
+		}
+
+		if len(sep) == 0 {
+			break
+		}
+
+		for _, v := range strings.Split(val, sep) {
+			if err := setBase(v, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		return setMapPairs(val, sep, value, false)
+	case reflect.Array:
+		return setArray(val, sep, value)
+	}
+
+	return setBase(val, value)
 }
 
 func setTimeDuration(val string, bitSize int, value reflect.Value) error {
@@ -138,7 +231,32 @@ func setTimeDuration(val string, bitSize int, value reflect.Value) error {
 	return nil
 }
 
+// The value parses itself, such as the flag.Value of flag.Var
+type setter interface {
+	Set(string) error
+}
+
+var (
+	setterType          = reflect.TypeOf((*setter)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// The type parses itself with Set or UnmarshalText
+func isSelfParsing(t reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	return pt.Implements(setterType) || pt.Implements(textUnmarshalerType)
+}
+
 func setBase(val string, value reflect.Value) error {
+	if value.CanAddr() {
+		switch v := value.Addr().Interface().(type) {
+		case setter:
+			return v.Set(val)
+		case encoding.TextUnmarshaler:
+			return v.UnmarshalText([]byte(val))
+		}
+	}
+
 	if value.Kind() == reflect.String {
 		value.SetString(val)
 		return nil
@@ -0,0 +1,252 @@
+package screw
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const optPrintConfig = "print-config"
+
+// SourceKind is where the value of a field came from
+type SourceKind int
+
+const (
+	//The field is not set
+	SourceNone SourceKind = iota
+	SourceDefault
+	SourceEnv
+	//The value came from a configuration instead of the command line
+	SourceConfig
+	SourceFlag
+	SourcePositional
+	//The value was typed in by the user, see Prompter
+	SourcePrompt
+)
+
+var sourceNames = [...]string{"none", "default", "env", "config", "flag", "positional", "prompt"}
+
+func (k SourceKind) String() string {
+	if k < 0 || int(k) >= len(sourceNames) {
+		return "SourceKind(" + strconv.Itoa(int(k)) + ")"
+	}
+	return sourceNames[k]
+}
+
+// Source is the provenance of the final value of a field
+type Source struct {
+	Kind SourceKind
+	//The raw string of the value, the last one when the option is repeated
+	Raw string
+	//The option, env or args name that set the value, such as --port, -p, PORT or <files>
+	Name string
+	//The index of the argument holding the value in the parsed arguments, -1 if the value is not from the command line
+	Index int
+}
+
+func (s Source) String() string {
+	switch s.Kind {
+	case SourceFlag, SourcePositional:
+		return fmt.Sprintf("%s %s, argv[%d]", s.Kind, s.Name, s.Index)
+	case SourceEnv:
+		return fmt.Sprintf("%s %s", s.Kind, s.Name)
+	}
+	return s.Kind.String()
+}
+
+func (o *optionState) setSource(kind SourceKind, raw string, name string, index int) {
+	o.source = Source{Kind: kind, Raw: o.redact(raw), Name: name, Index: index}
+}
+
+// Enable the built-in --print-config option, it prints the resolved structures with the source of each value.
+// It must be called before the structures are registered
+func (c *Screw) SetPrintConfig(enable bool) *Screw {
+	c.printConfig = enable
+	return c
+}
+
+// Report where the value of the field came from, the field is the Go field name, such as Port or Serve.Port
+func (c *Screw) Source(field string) Source {
+	if c.binder == nil {
+		return Source{Index: -1}
+	}
+	return c.binder.Source(field)
+}
+
+// Print the resolved structures, see Binder.PrintConfig
+func (c *Screw) PrintConfig(w io.Writer) {
+	if c.binder != nil {
+		c.binder.PrintConfig(w)
+	}
+}
+
+// Report where the value of the field came from, the field is the Go field name, such as Port or Serve.Port
+func (b *Binder) Source(field string) Source {
+	p, ok := b.cmd.lookupField(field)
+	if !ok {
+		return Source{Index: -1}
+	}
+
+	for _, o := range b.cmd.allOptions() {
+		if o.field.equal(p) {
+			return b.source(o)
+		}
+	}
+
+	return b.defaultSource(p)
+}
+
+func (b *Binder) source(o *Option) Source {
+	if s := b.states[o.id]; s.Option != nil && s.source.Kind != SourceNone {
+		return s.source
+	}
+	s := b.defaultSource(o.field)
+	s.Raw = o.redact(s.Raw)
+	return s
+}
+
+func (b *Binder) defaultSource(p fieldPath) Source {
+	for _, d := range b.cmd.defaults {
+		if d.field.equal(p) {
+			return Source{Kind: SourceDefault, Raw: d.value, Index: -1}
+		}
+	}
+	return Source{Index: -1}
+}
+
+// Print the options of the root structures and the set subcommands, one per line with the source of the value, such as
+//
+//	Port = 8080  # flag --port, argv[1]
+func (b *Binder) PrintConfig(w io.Writer) {
+	for _, o := range b.cmd.setOptions(b) {
+		//The nil pointers are not allocated for printing
+		value := "nil"
+		if v, ok := b.lookupValue(o.field); ok {
+			value = fmt.Sprint(v.Interface())
+			if !v.IsZero() {
+				value = o.redact(value)
+			}
+			if v.Kind() == reflect.String {
+				value = strconv.Quote(value)
+			}
+		}
+
+		fmt.Fprintf(w, "%s = %s  # %s\n", b.cmd.fieldName(o.field), value, b.source(o))
+	}
+}
+
+// The options of the command and the set subcommands, in the order of the registration
+func (c *command) setOptions(b *Binder) []*Option {
+	options := c.options()
+	for name, sub := range c.subcommand {
+		if b.IsSetSubcommand(name) {
+			options = append(options, sub.setOptions(b)...)
+		}
+	}
+
+	sort.Slice(options, func(i, j int) bool { return options[i].id < options[j].id })
+	return options
+}
+
+// The options of the command and all the subcommands
+func (c *command) allOptions() []*Option {
+	options := c.options()
+	for _, sub := range c.subcommand {
+		options = append(options, sub.allOptions()...)
+	}
+	return options
+}
+
+// The options of the command level, without duplicates
+func (c *command) options() []*Option {
+	var options []*Option
+	used := make(map[*Option]struct{}, len(c.shortAndLong))
+	add := func(o *Option) {
+		if _, ok := used[o]; !ok {
+			used[o] = struct{}{}
+			options = append(options, o)
+		}
+	}
+
+	for _, o := range c.shortAndLong {
+		add(o)
+	}
+	for _, o := range c.envAndArgs {
+		add(o)
+	}
+
+	sort.Slice(options, func(i, j int) bool { return options[i].id < options[j].id })
+	return options
+}
+
+// Find the field by the Go field names split by dot, the fields of the embedded structures are promoted
+func (c *command) lookupField(name string) (fieldPath, bool) {
+	for root, t := range c.types {
+		p := fieldPath{root: root}
+		found := true
+		for _, n := range strings.Split(name, ".") {
+			for t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+
+			var sf reflect.StructField
+			if t.Kind() == reflect.Struct {
+				sf, found = t.FieldByName(n)
+			} else {
+				found = false
+			}
+			if !found {
+				break
+			}
+
+			for _, i := range sf.Index {
+				p = p.child(i)
+			}
+			t = sf.Type
+		}
+
+		if found {
+			return p, true
+		}
+	}
+	return fieldPath{}, false
+}
+
+// The Go field names of the path, split by dot
+func (c *command) fieldName(p fieldPath) string {
+	var names []string
+	t := c.types[p.root]
+	for _, i := range p.index {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		sf := t.Field(i)
+		names = append(names, sf.Name)
+		t = sf.Type
+	}
+	return strings.Join(names, ".")
+}
+
+func (p fieldPath) equal(p2 fieldPath) bool {
+	if p.root != p2.root || len(p.index) != len(p2.index) {
+		return false
+	}
+
+	for i := range p.index {
+		if p.index[i] != p2.index[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func SetPrintConfig(enable bool) {
+	CommandLine.SetPrintConfig(enable)
+}
+
+func GetSource(field string) Source {
+	return CommandLine.Source(field)
+}
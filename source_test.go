@@ -0,0 +1,71 @@
+package screw
+
+import (
+	"bytes"
+	"testing"
+)
+
+type sourceServe struct {
+	Port int `screw:"-p;--port;env=PORT" default:"8080" usage:"port"`
+}
+
+type sourceConfig struct {
+	Name  string      `screw:"-n;--name" default:"app" usage:"name"`
+	Level int         `screw:"-l;--level;env=LEVEL" usage:"level"`
+	Debug bool        `screw:"-d" usage:"debug"`
+	Files []string    `screw:"args=files" usage:"files"`
+	Serve sourceServe `screw:"subcommand=serve" usage:"serve"`
+}
+
+func Test_Source(t *testing.T) {
+	env := []string{"LEVEL=3", "PORT=81"}
+	for _, test := range []struct {
+		args  []string
+		env   []string
+		field string
+		need  Source
+	}{
+		{args: nil, field: "Name", need: Source{Kind: SourceDefault, Raw: "app", Index: -1}},
+		{args: nil, env: env, field: "Level", need: Source{Kind: SourceEnv, Raw: "3", Name: "LEVEL", Index: -1}},
+		{args: nil, field: "Debug", need: Source{Index: -1}},
+		{args: []string{"-n", "x"}, field: "Name", need: Source{Kind: SourceFlag, Raw: "x", Name: "-n", Index: 1}},
+		{args: []string{"--name=x", "--name", "y"}, field: "Name", need: Source{Kind: SourceFlag, Raw: "y", Name: "--name", Index: 2}},
+		{args: []string{"-l", "5"}, field: "Level", need: Source{Kind: SourceFlag, Raw: "5", Name: "-l", Index: 1}},
+		{args: []string{"-dl5"}, field: "Level", need: Source{Kind: SourceFlag, Raw: "5", Name: "-l", Index: 0}},
+		{args: []string{"a", "-d", "b"}, field: "Files", need: Source{Kind: SourcePositional, Raw: "b", Name: "<files>", Index: 2}},
+		{args: []string{"serve"}, env: env, field: "Serve.Port", need: Source{Kind: SourceEnv, Raw: "81", Name: "PORT", Index: -1}},
+		{args: []string{"serve", "-p", "1"}, field: "Serve.Port", need: Source{Kind: SourceFlag, Raw: "1", Name: "-p", Index: 2}},
+		//The env takes precedence over the command line
+		{args: []string{"serve", "-p", "1"}, env: env, field: "Serve.Port", need: Source{Kind: SourceEnv, Raw: "81", Name: "PORT", Index: -1}},
+		{args: nil, field: "Unknown", need: Source{Index: -1}},
+	} {
+		var got sourceConfig
+		s := New(test.args).SetExit(false).SetEnviron(test.env)
+		if err := s.Bind(&got); err != nil {
+			t.Fatalf("%q: %v", test.args, err)
+		}
+
+		if src := s.Source(test.field); src != test.need {
+			t.Errorf("%q %s: got %+v, need %+v", test.args, test.field, src, test.need)
+		}
+	}
+}
+
+func Test_PrintConfig(t *testing.T) {
+	var got sourceConfig
+	var w bytes.Buffer
+	s := New([]string{"-n", "x", "--print-config", "serve", "-p", "1"}).SetExit(false).SetOutput(&w).SetPrintConfig(true).SetEnviron([]string{"LEVEL=3"})
+	if err := s.Bind(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	need := `Name = "x"  # flag -n, argv[1]
+Level = 3  # env LEVEL
+Debug = false  # none
+Files = []  # none
+Serve.Port = 1  # flag -p, argv[5]
+`
+	if w.String() != need {
+		t.Errorf("got\n%s\nneed\n%s", w.String(), need)
+	}
+}
@@ -0,0 +1,408 @@
+package screw
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var ErrTypeMismatch = errors.New("type does not match the spec")
+
+// Spec is the compiled form of the structure type.
+// It is immutable, so one Spec can be shared by any number of goroutines,
+// and every command line is parsed by a cheap Binder created from it
+type Spec struct {
+	cmd           *command
+	responseFiles bool
+	responseLines bool
+	w             io.Writer
+	prompter      Prompter
+	lookupEnv     func(string) (string, bool)
+	environ       []string
+}
+
+// Compile the structure type that x points to, x can be a nil pointer like (*Config)(nil)
+func Compile(x interface{}) (*Spec, error) {
+	return New(nil).Compile(x)
+}
+
+// MustCompile is similar to Compile function, and the error is direct panic
+func MustCompile(x interface{}) *Spec {
+	s, err := Compile(x)
+	if err != nil {
+		panic(err.Error())
+	}
+	return s
+}
+
+// Compile the registered structures and x into a Spec.
+// The Screw must not be used after that, it is owned by the Spec
+func (c *Screw) Compile(x interface{}) (*Spec, error) {
+	if x == nil {
+		return nil, ErrUnsupportedType
+	}
+
+	t := reflect.TypeOf(x)
+	if t.Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("%w:got(%T)", ErrNotPointerType, x)
+	}
+
+	if c.version == "" {
+		c.version = defautlVersion
+	}
+
+	if err := c.command.register(t); err != nil {
+		return nil, err
+	}
+
+	return &Spec{cmd: c.command, responseFiles: c.responseFiles, responseLines: c.responseLines, w: c.w, prompter: c.prompter, lookupEnv: c.lookupEnv, environ: c.environ}, nil
+}
+
+// Create a Binder that parses args
+func (s *Spec) NewBinder(args []string) *Binder {
+	b := newBinder(s.cmd, args)
+	b.responseFiles = s.responseFiles
+	b.responseLines = s.responseLines
+	b.prompter = s.prompter
+	b.lookupEnv = s.lookupEnv
+	b.environ = s.environ
+	b.w = s.w
+	return b
+}
+
+// Bind parses args into x, which has the same type as the compiled structure
+func (s *Spec) Bind(args []string, x ...interface{}) error {
+	return s.NewBinder(args).Bind(x...)
+}
+
+// Print Help
+func (s *Spec) Usage(w io.Writer) {
+	s.cmd.printHelpMessage(w, s.lookupEnv)
+}
+
+// Binder holds the state of one command line parsing.
+// It does not exit the process by default
+type Binder struct {
+	cmd     *command
+	args    []string
+	offsets []int
+	//The origins of the arguments after the response files are expanded
+	origins []argOrigin
+	roots   []reflect.Value
+	states  []optionState
+
+	isSetSubcommand map[string]struct{}
+	currSubcommand  *command
+
+	responseFiles bool
+	responseLines bool
+	exit          bool
+	w             io.Writer
+	prompter      Prompter
+	//Without a Prompter, the required values are asked for on the terminal, only a Screw sets it
+	terminal  bool
+	lookupEnv func(string) (string, bool)
+	environ   []string
+	//--print-config is given
+	showConfig  bool
+	occurrences []Occurrence
+}
+
+func newBinder(cmd *command, args []string) *Binder {
+	return &Binder{
+		cmd:             cmd,
+		args:            args,
+		states:          make([]optionState, cmd.numOptions),
+		isSetSubcommand: make(map[string]struct{}),
+		w:               os.Stdout,
+	}
+}
+
+// Set the behavior of -h and -v, true means exit the process after printing
+func (b *Binder) SetExit(exit bool) *Binder {
+	b.exit = exit
+	return b
+}
+
+func (b *Binder) SetOutput(w io.Writer) *Binder {
+	b.w = w
+	return b
+}
+
+// See Screw.SetEnv
+func (b *Binder) SetEnv(lookup func(string) (string, bool)) *Binder {
+	b.lookupEnv = lookup
+	b.environ = nil
+	return b
+}
+
+// See Screw.SetEnviron
+func (b *Binder) SetEnviron(environ []string) *Binder {
+	b.SetEnv(Environ(environ))
+	b.environ = append([]string{}, environ...)
+	return b
+}
+
+func (b *Binder) getEnv(name string) (string, bool) {
+	if b.lookupEnv != nil {
+		return b.lookupEnv(name)
+	}
+	return os.LookupEnv(name)
+}
+
+// Bind the structures, they must be pointers to the compiled types, in the same order
+func (b *Binder) Bind(x ...interface{}) error {
+	roots, err := b.checkRoots(x)
+	if err != nil {
+		return err
+	}
+
+	return b.bind(roots)
+}
+
+func (b *Binder) checkRoots(x []interface{}) ([]reflect.Value, error) {
+	if len(x) != len(b.cmd.types) {
+		return nil, fmt.Errorf("%w: want %d structures, got %d", ErrTypeMismatch, len(b.cmd.types), len(x))
+	}
+
+	roots := make([]reflect.Value, len(x))
+	for i, x := range x {
+		if x == nil {
+			return nil, ErrUnsupportedType
+		}
+
+		v := reflect.ValueOf(x)
+		if v.Type() != b.cmd.types[i] {
+			return nil, fmt.Errorf("%w: want %s, got %T", ErrTypeMismatch, b.cmd.types[i], x)
+		}
+
+		if v.IsNil() {
+			return nil, ErrUnsupportedType
+		}
+		roots[i] = v
+	}
+	return roots, nil
+}
+
+func (b *Binder) setDefaults() error {
+	for _, d := range b.cmd.defaults {
+		if err := setDefaultValue(d.value, d.sep, d.layout, b.fieldValue(d.field)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Binder) bind(roots []reflect.Value) error {
+	b.roots = roots
+	if err := b.setDefaults(); err != nil {
+		return err
+	}
+
+	if err := b.newParser(b.cmd, b.args, 0).bindStruct(); err != nil {
+		return err
+	}
+
+	if err := b.checkArrays(); err != nil {
+		return err
+	}
+
+	if err := b.prompt(); err != nil {
+		return err
+	}
+
+	if err := b.validate(); err != nil {
+		return err
+	}
+
+	if b.showConfig {
+		b.PrintConfig(b.w)
+		if b.exit {
+			os.Exit(0)
+		}
+	}
+	return nil
+}
+
+func (b *Binder) validate() error {
+	for i, x := range b.roots {
+		//Only the set subcommands need data verification
+		//Replace the root structure here
+		if b.currSubcommand != nil && b.currSubcommand.field.root == i {
+			x = b.fieldValue(b.currSubcommand.field).Addr()
+		}
+
+		if err := valid.ValidateStruct(x.Interface()); err != nil {
+			errs := err.(validator.ValidationErrors)
+
+			for _, e := range errs {
+				// can translate each error one at a time.
+				return errors.New(e.Translate(valid.trans))
+			}
+		}
+	}
+	return nil
+}
+
+func (b *Binder) IsSetSubcommand(subcommand string) bool {
+	_, ok := b.isSetSubcommand[subcommand]
+	return ok
+}
+
+func (b *Binder) GetIndex(optName string) uint64 {
+	o, ok := b.cmd.shortAndLong[optName]
+	if ok {
+		return b.states[o.id].index
+	}
+	if _, ok := b.cmd.checkArgs[optName]; ok {
+		for _, o := range b.cmd.envAndArgs {
+			if o.argsName == optName {
+				return b.states[o.id].index
+			}
+		}
+	}
+
+	return 0
+}
+
+// Get the per-invocation state of the option, the field is resolved on first use
+func (b *Binder) state(o *Option) *optionState {
+	s := &b.states[o.id]
+	if s.Option == nil {
+		s.Option = o
+		s.b = b
+	}
+	return s
+}
+
+// Get the field value without allocating the nil pointers on the way, false if one is nil
+func (b *Binder) lookupValue(p fieldPath) (reflect.Value, bool) {
+	v, ok := deref(b.roots[p.root])
+	for _, i := range p.index {
+		if !ok {
+			return v, false
+		}
+		v, ok = deref(v.Field(i))
+	}
+	return v, ok
+}
+
+func deref(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return v, false
+		}
+		v = v.Elem()
+	}
+	return v, true
+}
+
+// Get the field value, nil pointers on the way are allocated
+func (b *Binder) fieldValue(p fieldPath) reflect.Value {
+	v := indirect(b.roots[p.root])
+	for _, i := range p.index {
+		v = indirect(v.Field(i))
+	}
+	return v
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// cmdParser parses the arguments of one command level
+type cmdParser struct {
+	*Binder
+	*command
+	args         []string
+	unparsedArgs []unparsedArg
+	//The position of args[0] in the arguments of the Binder
+	base int
+}
+
+func (b *Binder) newParser(cmd *command, args []string, base int) *cmdParser {
+	return &cmdParser{Binder: b, command: cmd, args: args, base: base}
+}
+
+// optionState is the per-invocation part of the Option
+type optionState struct {
+	*Option
+	pointer reflect.Value
+	b       *Binder
+	//Indicates the parameter priority. The high 4 bytes store the args sequence,
+	//and the low 4 bytes store the command combination sequence (ls ltr).
+	//The value of the high 4 bytes of l here is 0
+	index  uint64
+	cmdSet bool
+	source Source
+	//The number of the elements of the array that are set
+	arrayLen int
+}
+
+func (o *optionState) onceResetValue() {
+	if len(o.showDefValue) > 0 && !o.value().IsZero() && !o.cmdSet {
+		resetValue(o.settable())
+	}
+
+	o.cmdSet = true
+}
+
+// The field to read, it is the zero value if a nil pointer on the way is not allocated yet
+func (o *optionState) value() reflect.Value {
+	if o.pointer.IsValid() {
+		return o.pointer
+	}
+
+	v, ok := o.b.lookupValue(o.field)
+	if !ok {
+		return reflect.Zero(o.typ)
+	}
+	o.pointer = v
+	return v
+}
+
+// The field to set, the nil pointers on the way are allocated
+func (o *optionState) settable() reflect.Value {
+	if !o.pointer.IsValid() {
+		o.pointer = o.b.fieldValue(o.field)
+	}
+	return o.pointer
+}
+
+// The callback method, the structure that owns it is allocated
+func (o *optionState) callback() reflect.Value {
+	return o.b.fieldValue(o.field.parent()).Addr().MethodByName(o.fnName)
+}
+
+// fieldPath is the location of a field, starting from one of the registered structures
+type fieldPath struct {
+	root  int
+	index []int
+}
+
+func (p fieldPath) child(i int) fieldPath {
+	index := make([]int, len(p.index), len(p.index)+1)
+	copy(index, p.index)
+	return fieldPath{root: p.root, index: append(index, i)}
+}
+
+func (p fieldPath) parent() fieldPath {
+	return fieldPath{root: p.root, index: p.index[:len(p.index)-1]}
+}
+
+type defaultValue struct {
+	field  fieldPath
+	value  string
+	sep    string
+	layout string
+}
@@ -0,0 +1,146 @@
+package screw
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+type specServe struct {
+	Port  int      `screw:"-p;--port" default:"8080" usage:"port"`
+	Hosts []string `screw:"--host" usage:"hosts"`
+}
+
+type specConfig struct {
+	Debug bool      `screw:"-d;--debug" usage:"debug"`
+	Name  string    `screw:"--name" default:"app" usage:"name"`
+	Files []string  `screw:"args=files" usage:"files"`
+	Serve specServe `screw:"subcommand=serve" usage:"serve"`
+}
+
+func Test_Spec_Bind(t *testing.T) {
+	spec := MustCompile((*specConfig)(nil))
+	for _, test := range []struct {
+		args []string
+		need specConfig
+		sub  bool
+	}{
+		{
+			args: []string{},
+			need: specConfig{Name: "app", Serve: specServe{Port: 8080}},
+		},
+		{
+			args: []string{"-d", "--name", "x", "a.txt", "b.txt"},
+			need: specConfig{Debug: true, Name: "x", Files: []string{"a.txt", "b.txt"}, Serve: specServe{Port: 8080}},
+		},
+		{
+			args: []string{"serve", "-p", "9090", "--host", "a", "--host", "b"},
+			need: specConfig{Name: "app", Serve: specServe{Port: 9090, Hosts: []string{"a", "b"}}},
+			sub:  true,
+		},
+	} {
+		var got specConfig
+		b := spec.NewBinder(test.args)
+		if err := b.Bind(&got); err != nil {
+			t.Fatalf("%q: %v", test.args, err)
+		}
+
+		if !reflect.DeepEqual(got, test.need) {
+			t.Errorf("%q: got %+v, need %+v", test.args, got, test.need)
+		}
+		if b.IsSetSubcommand("serve") != test.sub {
+			t.Errorf("%q: IsSetSubcommand(serve) = %t", test.args, !test.sub)
+		}
+	}
+}
+
+func Test_Spec_TypeMismatch(t *testing.T) {
+	spec := MustCompile((*specConfig)(nil))
+	for _, x := range []interface{}{
+		&specServe{},
+		specConfig{},
+		(*specConfig)(nil),
+	} {
+		if err := spec.Bind(nil, x); err == nil {
+			t.Errorf("%T: no error", x)
+		}
+	}
+
+	err := spec.Bind(nil, &specServe{})
+	if !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("got %v, need ErrTypeMismatch", err)
+	}
+
+	if err := spec.Bind(nil); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("got %v, need ErrTypeMismatch", err)
+	}
+}
+
+// The Spec is shared by the goroutines, run with -race
+func Test_Spec_Concurrent(t *testing.T) {
+	spec := MustCompile((*specConfig)(nil))
+	spec.w = ioutil.Discard
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			var got specConfig
+			port := strconv.Itoa(1000 + i)
+			name := fmt.Sprintf("n%d", i)
+			args := []string{"--name", name, "f" + port}
+			if i%2 == 0 {
+				args = []string{"--name", name, "serve", "--port", port, "--host", name}
+			}
+
+			//The help reads the compiled command too
+			spec.Usage(ioutil.Discard)
+
+			b := spec.NewBinder(args)
+			if err := b.Bind(&got); err != nil {
+				errs <- err
+				return
+			}
+
+			need := specConfig{Name: name, Files: []string{"f" + port}, Serve: specServe{Port: 8080}}
+			if i%2 == 0 {
+				need = specConfig{Name: name, Serve: specServe{Port: 1000 + i, Hosts: []string{name}}}
+			}
+			if !reflect.DeepEqual(got, need) || b.IsSetSubcommand("serve") != (i%2 == 0) {
+				errs <- fmt.Errorf("%q: got %+v, need %+v", args, got, need)
+			}
+			if s := b.Source("Name"); s.Kind != SourceFlag || s.Raw != name {
+				errs <- fmt.Errorf("%q: the source of Name is %+v", args, s)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// The Binder state is not shared, a default set by one binding is set again by the next
+func Test_Spec_Defaults(t *testing.T) {
+	spec := MustCompile((*specConfig)(nil))
+	var a, b specConfig
+	if err := spec.Bind([]string{"--name", "x", "serve", "-p", "1"}, &a); err != nil {
+		t.Fatal(err)
+	}
+	if err := spec.Bind(nil, &b); err != nil {
+		t.Fatal(err)
+	}
+
+	if b.Name != "app" || b.Serve.Port != 8080 {
+		t.Errorf("got %+v", b)
+	}
+}
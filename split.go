@@ -0,0 +1,162 @@
+package screw
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// OffsetError reports the byte offset in the command line string where the error happened
+type OffsetError struct {
+	Offset int
+	Err    error
+}
+
+func (e *OffsetError) Error() string {
+	return fmt.Sprintf("offset %d: %s", e.Offset, e.Err)
+}
+
+func (e *OffsetError) Unwrap() error {
+	return e.Err
+}
+
+// Split the command line into arguments with the POSIX shell rules.
+// Blanks separate the words, single quotes keep everything literally, double quotes and backslash escape,
+// and # starts a comment at the beginning of a word.
+// There is no variable or glob expansion, and characters like ; | & are ordinary characters
+func Split(cmdline string) ([]string, error) {
+	words, _, err := splitWords(cmdline)
+	return words, err
+}
+
+// Split the words and record the byte offset where each word starts
+func splitWords(s string) (words []string, offsets []int, err error) {
+	var word strings.Builder
+	inWord := false
+	start := 0
+
+	begin := func(i int) {
+		if !inWord {
+			inWord = true
+			start = i
+		}
+	}
+
+	for i := 0; i < len(s); i++ {
+		b := s[i]
+		switch {
+		case b == ' ' || b == '\t' || b == '\n':
+			if inWord {
+				words = append(words, word.String())
+				offsets = append(offsets, start)
+				word.Reset()
+				inWord = false
+			}
+		case b == '#' && !inWord:
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case b == '\\':
+			if i+1 >= len(s) {
+				return nil, nil, &OffsetError{Offset: i, Err: errors.New("trailing backslash")}
+			}
+			//Backslash newline is a line continuation
+			if s[i+1] == '\n' {
+				i++
+				continue
+			}
+			begin(i)
+			i++
+			word.WriteByte(s[i])
+		case b == '\'':
+			begin(i)
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end == -1 {
+				return nil, nil, &OffsetError{Offset: i, Err: errors.New("unterminated single quote")}
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+		case b == '"':
+			begin(i)
+			j := i + 1
+		quote:
+			for ; j < len(s); j++ {
+				switch s[j] {
+				case '"':
+					break quote
+				case '\\':
+					//In double quotes, backslash only escapes $ ` " \ and newline
+					if j+1 < len(s) && strings.IndexByte("$`\"\\\n", s[j+1]) != -1 {
+						j++
+						if s[j] != '\n' {
+							word.WriteByte(s[j])
+						}
+						continue
+					}
+					word.WriteByte('\\')
+				default:
+					word.WriteByte(s[j])
+				}
+			}
+			if j >= len(s) {
+				return nil, nil, &OffsetError{Offset: i, Err: errors.New("unterminated double quote")}
+			}
+			i = j
+		default:
+			begin(i)
+			word.WriteByte(b)
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+		offsets = append(offsets, start)
+	}
+
+	return words, offsets, nil
+}
+
+// NewFromString is similar to New function, the arguments are split from one command line string.
+// Errors found while parsing report the byte offset of the argument in cmdline
+func NewFromString(cmdline string) (*Screw, error) {
+	args, offsets, err := splitWords(cmdline)
+	if err != nil {
+		return nil, err
+	}
+
+	c := New(args)
+	c.offsets = offsets
+	return c, nil
+}
+
+// Create a Binder that parses the command line string, see NewFromString
+func (s *Spec) NewBinderFromString(cmdline string) (*Binder, error) {
+	args, offsets, err := splitWords(cmdline)
+	if err != nil {
+		return nil, err
+	}
+
+	b := s.NewBinder(args)
+	b.offsets = offsets
+	return b, nil
+}
+
+// Point the error at the argument, the file and line when it is read from a response file,
+// and the offset when the arguments come from a command line string
+func (c *cmdParser) argError(index int, err error) error {
+	var re *originError
+	if c.base+index < len(c.origins) && !errors.As(err, &re) {
+		err = &originError{argOrigin: c.origins[c.base+index], err: err}
+	}
+
+	if c.offsets == nil || c.base+index >= len(c.offsets) {
+		return err
+	}
+
+	var oe *OffsetError
+	if errors.As(err, &oe) {
+		return err
+	}
+
+	return &OffsetError{Offset: c.offsets[c.base+index], Err: err}
+}
@@ -0,0 +1,127 @@
+package screw
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_Split(t *testing.T) {
+	for _, test := range []struct {
+		in      string
+		words   []string
+		offsets []int
+	}{
+		{in: "", words: nil, offsets: nil},
+		{in: "  \t\n ", words: nil, offsets: nil},
+		{in: "a b  c", words: []string{"a", "b", "c"}, offsets: []int{0, 2, 5}},
+		{in: "  --name=x\t-v", words: []string{"--name=x", "-v"}, offsets: []int{2, 11}},
+		{in: `'a b' "c d"`, words: []string{"a b", "c d"}, offsets: []int{0, 6}},
+		{in: `--msg='it''s'`, words: []string{"--msg=its"}, offsets: []int{0}},
+		{in: `'' ""`, words: []string{"", ""}, offsets: []int{0, 3}},
+		{in: `a\ b c`, words: []string{"a b", "c"}, offsets: []int{0, 5}},
+		{in: `'$x \n'`, words: []string{`$x \n`}, offsets: []int{0}},
+		{in: `"a\"b\\c\$d\e"`, words: []string{`a"b\c$d\e`}, offsets: []int{0}},
+		{in: "a \\\nb", words: []string{"a", "b"}, offsets: []int{0, 4}},
+		{in: "\"a\\\nb\"", words: []string{"ab"}, offsets: []int{0}},
+		{in: "a # comment\nb", words: []string{"a", "b"}, offsets: []int{0, 12}},
+		{in: "a#b", words: []string{"a#b"}, offsets: []int{0}},
+		{in: "a;b|c&d", words: []string{"a;b|c&d"}, offsets: []int{0}},
+		{in: "x 你好 y", words: []string{"x", "你好", "y"}, offsets: []int{0, 2, 9}},
+	} {
+		words, offsets, err := splitWords(test.in)
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(words, test.words) || !reflect.DeepEqual(offsets, test.offsets) {
+			t.Errorf("%q: got %q %v, need %q %v", test.in, words, offsets, test.words, test.offsets)
+		}
+	}
+}
+
+func Test_Split_Error(t *testing.T) {
+	for _, test := range []struct {
+		in     string
+		offset int
+	}{
+		{in: `a 'b`, offset: 2},
+		{in: `a "b\"`, offset: 2},
+		{in: `ab\`, offset: 2},
+		{in: `"ok" x'`, offset: 6},
+	} {
+		_, err := Split(test.in)
+		var oe *OffsetError
+		if !errors.As(err, &oe) {
+			t.Errorf("%q: got %v, need an OffsetError", test.in, err)
+			continue
+		}
+
+		if oe.Offset != test.offset {
+			t.Errorf("%q: got offset %d, need %d", test.in, oe.Offset, test.offset)
+		}
+	}
+}
+
+type splitServe struct {
+	Port int `screw:"-p;--port" usage:"port"`
+}
+
+type splitConfig struct {
+	Count int        `screw:"-c;--count" usage:"count"`
+	Level string     `screw:"--level" choices:"debug,info" usage:"level"`
+	Serve splitServe `screw:"subcommand=serve" usage:"serve"`
+}
+
+// The parsing errors point at the argument in the command line string, the value if it is a separate word
+func Test_NewFromString_Offset(t *testing.T) {
+	spec := MustCompile((*splitConfig)(nil))
+	for _, test := range []struct {
+		in     string
+		offset int
+	}{
+		{in: "-c x", offset: 3},
+		{in: "--count=1 --count=x", offset: 10},
+		{in: "-c 1   --level 'trace'", offset: 15},
+		{in: "'--count' 1 --bad", offset: 12},
+		{in: "-c 2 serve  --port nope", offset: 19},
+	} {
+		b, err := spec.NewBinderFromString(test.in)
+		if err != nil {
+			t.Fatalf("%q: %v", test.in, err)
+		}
+
+		err = b.Bind(new(splitConfig))
+		var oe *OffsetError
+		if !errors.As(err, &oe) {
+			t.Errorf("%q: got %v, need an OffsetError", test.in, err)
+			continue
+		}
+
+		if oe.Offset != test.offset {
+			t.Errorf("%q: got offset %d, need %d (%v)", test.in, oe.Offset, test.offset, err)
+		}
+	}
+}
+
+func Test_NewFromString(t *testing.T) {
+	c, err := NewFromString(`-c 3 serve --port "80"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got splitConfig
+	if err := c.SetExit(false).Bind(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	need := splitConfig{Count: 3, Serve: splitServe{Port: 80}}
+	if got != need {
+		t.Errorf("got %+v, need %+v", got, need)
+	}
+
+	if _, err := NewFromString(`--level 'x`); err == nil {
+		t.Error("no error for the unterminated quote")
+	}
+}
@@ -0,0 +1,460 @@
+package screw
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The header of the generated files, the files with it are skipped when the package is read
+const staticGenHeader = "// Code generated by screw-gen. DO NOT EDIT."
+
+// StaticGen generates a ParseXxx(args []string) (*Xxx, error) function for each tagged structure.
+// The generated code has the same semantics as Bind and uses no reflection
+type StaticGen struct {
+	dir   string
+	types []string
+}
+
+// Create a generator of the structures of the package in dir
+func NewStaticGen(dir string) *StaticGen {
+	return &StaticGen{dir: dir}
+}
+
+// Set the names of the structures
+func (g *StaticGen) Type(names ...string) *StaticGen {
+	g.types = append(g.types, names...)
+	return g
+}
+
+// The basic types that the generated code parses
+var staticBasicTypes = map[string]reflect.Type{
+	"bool":    reflect.TypeOf(false),
+	"byte":    reflect.TypeOf(uint8(0)),
+	"float32": reflect.TypeOf(float32(0)),
+	"float64": reflect.TypeOf(float64(0)),
+	"int":     reflect.TypeOf(int(0)),
+	"int16":   reflect.TypeOf(int16(0)),
+	"int32":   reflect.TypeOf(int32(0)),
+	"int64":   reflect.TypeOf(int64(0)),
+	"int8":    reflect.TypeOf(int8(0)),
+	"rune":    reflect.TypeOf(rune(0)),
+	"string":  reflect.TypeOf(""),
+	"uint":    reflect.TypeOf(uint(0)),
+	"uint16":  reflect.TypeOf(uint16(0)),
+	"uint32":  reflect.TypeOf(uint32(0)),
+	"uint64":  reflect.TypeOf(uint64(0)),
+	"uint8":   reflect.TypeOf(uint8(0)),
+}
+
+// The types of other packages that parse themselves with UnmarshalText, the value is the zero check
+var staticTextTypes = map[string]string{
+	"net.IP":         "%s == nil",
+	"netip.Addr":     "%s == (netip.Addr{})",
+	"netip.AddrPort": "%s == (netip.AddrPort{})",
+	"netip.Prefix":   "%s == (netip.Prefix{})",
+	"screw.ByteSize": "%s == 0",
+	"time.Time":      "%s.IsZero()",
+}
+
+// A structure, the options and the subcommands are its fields
+type genStruct struct {
+	//The name of the type, empty for the struct literal types
+	name   string
+	typ    reflect.Type
+	fields []*genField
+}
+
+// A field of the structure, the fields are in the order of the fields of genStruct.typ
+type genField struct {
+	name     string
+	typeName string
+	kind     reflect.Kind
+	//The scalar value, the element of the slice or the value of the map
+	leaf *genLeaf
+	key  *genLeaf
+	sub  *genStruct
+	//The method of the owner called with the value instead of setting the field
+	callback string
+}
+
+// A value the generated code parses from a string
+type genLeaf struct {
+	typeName string
+	kind     reflect.Kind
+	//The type the spec is compiled with, self-parsing types use their underlying basic type or string
+	rtype reflect.Type
+	//Set or UnmarshalText
+	setter string
+	//The format of the zero check
+	zero string
+}
+
+type staticGen struct {
+	*StaticGen
+	pkgName string
+	specs   map[string]*ast.TypeSpec
+	methods map[string]map[string]bool
+	imports map[string]string
+}
+
+// Generate the code of the structures
+func (g *StaticGen) Generate() ([]byte, error) {
+	if len(g.types) == 0 {
+		return nil, fmt.Errorf("no type is given")
+	}
+
+	sg := &staticGen{
+		StaticGen: g,
+		specs:     make(map[string]*ast.TypeSpec),
+		methods:   make(map[string]map[string]bool),
+		imports:   make(map[string]string),
+	}
+
+	if err := sg.load(); err != nil {
+		return nil, err
+	}
+
+	var code strings.Builder
+	for _, name := range g.types {
+		s, err := sg.structOf(name, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := sg.genParse(&code, name, s); err != nil {
+			return nil, err
+		}
+	}
+
+	return sg.genFile(code.String())
+}
+
+// Read the type declarations, the methods and the imports of the package
+func (sg *staticGen) load() error {
+	infos, err := ioutil.ReadDir(sg.dir)
+	if err != nil {
+		return err
+	}
+
+	fset := token.NewFileSet()
+	for _, fi := range infos {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		src, err := ioutil.ReadFile(filepath.Join(sg.dir, name))
+		if err != nil {
+			return err
+		}
+
+		if strings.Contains(string(src), staticGenHeader) {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, name, src, 0)
+		if err != nil {
+			return err
+		}
+		sg.pkgName = f.Name.Name
+
+		for _, imp := range f.Imports {
+			path, _ := strconv.Unquote(imp.Path.Value)
+			sg.imports[importSpecName(imp, path)] = path
+		}
+
+		for _, d := range f.Decls {
+			switch d := d.(type) {
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					if ts, ok := spec.(*ast.TypeSpec); ok {
+						sg.specs[ts.Name.Name] = ts
+					}
+				}
+			case *ast.FuncDecl:
+				if d.Recv == nil || len(d.Recv.List) == 0 {
+					continue
+				}
+
+				recv := d.Recv.List[0].Type
+				if star, ok := recv.(*ast.StarExpr); ok {
+					recv = star.X
+				}
+
+				if ident, ok := recv.(*ast.Ident); ok {
+					if sg.methods[ident.Name] == nil {
+						sg.methods[ident.Name] = make(map[string]bool)
+					}
+					sg.methods[ident.Name][d.Name.Name] = true
+				}
+			}
+		}
+	}
+
+	if len(sg.pkgName) == 0 {
+		return fmt.Errorf("no go files in %s", sg.dir)
+	}
+	return nil
+}
+
+// Resolve the structure type, stack holds the structures being resolved to find the cycles
+func (sg *staticGen) structOf(name string, st *ast.StructType, stack []string) (*genStruct, error) {
+	if len(name) > 0 {
+		for _, s := range stack {
+			if s == name {
+				return nil, fmt.Errorf("type %s refers to itself", name)
+			}
+		}
+		stack = append(stack, name)
+
+		ts, ok := sg.specs[name]
+		if !ok {
+			return nil, fmt.Errorf("type %s is not found in %s", name, sg.dir)
+		}
+
+		if st, ok = ts.Type.(*ast.StructType); !ok {
+			return nil, fmt.Errorf("type %s is not a struct", name)
+		}
+	}
+
+	s := &genStruct{name: name}
+	var fields []reflect.StructField
+	for _, f := range st.Fields.List {
+		var tag string
+		if f.Tag != nil {
+			tag, _ = strconv.Unquote(f.Tag.Value)
+		}
+
+		names := f.Names
+		if len(names) == 0 {
+			//The embedded field is named after its type
+			embedded := f.Type
+			if sel, ok := embedded.(*ast.SelectorExpr); ok {
+				embedded = sel.Sel
+			}
+			ident, ok := embedded.(*ast.Ident)
+			if !ok {
+				return nil, fmt.Errorf("%s: unsupported embedded field %s", name, types.ExprString(f.Type))
+			}
+			names = []*ast.Ident{ident}
+		} else {
+			//The unexported fields are not options
+			var exported []*ast.Ident
+			for _, n := range names {
+				if ast.IsExported(n.Name) {
+					exported = append(exported, n)
+				}
+			}
+			names = exported
+		}
+
+		for _, n := range names {
+			field, sf, err := sg.fieldOf(s, n.Name, f.Type, tag, stack)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", name, n.Name, err)
+			}
+			if field == nil {
+				continue
+			}
+
+			s.fields = append(s.fields, field)
+			fields = append(fields, sf)
+		}
+	}
+
+	s.typ = reflect.StructOf(fields)
+	return s, nil
+}
+
+// Resolve the field and the field of the structure the spec is compiled with, nil if the field is skipped
+func (sg *staticGen) fieldOf(owner *genStruct, name string, expr ast.Expr, tag string, stack []string) (*genField, reflect.StructField, error) {
+	f := &genField{name: name, typeName: types.ExprString(expr)}
+	sf := reflect.StructField{Name: name}
+	if !ast.IsExported(name) {
+		sf.Name = "X" + name
+	}
+
+	tags := Tag(tag)
+	screw := tags.Get("screw")
+	for _, key := range []string{"valid", "layout"} {
+		if _, ok := tags.Lookup(key); ok {
+			return nil, sf, fmt.Errorf("the %s tag is not supported by the generated code", key)
+		}
+	}
+
+	//The callback is called by the generated code, it is removed from the tag the spec is compiled with
+	var opts []string
+	for _, opt := range strings.Split(screw, ";") {
+		trim := strings.TrimLeft(opt, optSpace)
+		switch {
+		case trim == optCallback:
+			f.callback = defautlCallbackName
+		case strings.HasPrefix(trim, optCallbackEqual):
+			f.callback = trim[len(optCallbackEqual):]
+		case trim == optSecret:
+			return nil, sf, fmt.Errorf("the %s option is not supported by the generated code", optSecret)
+		default:
+			opts = append(opts, opt)
+		}
+	}
+	screw = strings.Join(opts, ";")
+
+	if len(f.callback) > 0 && !sg.methods[owner.name][f.callback] {
+		return nil, sf, fmt.Errorf("the callback %s is not a method of %s", f.callback, owner.name)
+	}
+
+	var err error
+	switch e := expr.(type) {
+	case *ast.ArrayType:
+		if e.Len == nil {
+			f.kind = reflect.Slice
+			if f.leaf, err = sg.leafOf(e.Elt); err == nil {
+				sf.Type = reflect.SliceOf(f.leaf.rtype)
+			}
+		}
+	case *ast.MapType:
+		f.kind = reflect.Map
+		if f.key, err = sg.leafOf(e.Key); err == nil && len(f.key.setter) == 0 {
+			if f.leaf, err = sg.leafOf(e.Value); err == nil {
+				sf.Type = reflect.MapOf(f.key.rtype, f.leaf.rtype)
+			}
+		}
+	default:
+		if f.leaf, err = sg.leafOf(expr); err == nil {
+			f.kind = f.leaf.kind
+			sf.Type = f.leaf.rtype
+			break
+		}
+
+		//The structures are the groups of options or the subcommands
+		var st *ast.StructType
+		typeName := ""
+		switch e := expr.(type) {
+		case *ast.StructType:
+			st = e
+		case *ast.Ident:
+			if ts, ok := sg.specs[e.Name]; ok {
+				if _, ok := ts.Type.(*ast.StructType); ok {
+					typeName = e.Name
+				}
+			}
+		}
+
+		if st != nil || len(typeName) > 0 {
+			f.kind = reflect.Struct
+			if f.sub, err = sg.structOf(typeName, st, stack); err != nil {
+				return nil, sf, err
+			}
+			sf.Type = f.sub.typ
+			err = nil
+		}
+	}
+
+	if sf.Type == nil {
+		//The fields that screw ignores can have any type
+		if len(screw) == 0 && len(tags.Get("usage")) == 0 && len(tags.Get("default")) == 0 {
+			return nil, sf, nil
+		}
+		if err == nil {
+			err = fmt.Errorf("unsupported type %s", f.typeName)
+		}
+		return nil, sf, err
+	}
+
+	//Only the tags screw reads are kept
+	var st strings.Builder
+	st.WriteString("screw:" + strconv.Quote(screw))
+	for _, key := range []string{"usage", "default", "sep", "choices", "prefix", "envprefix"} {
+		if v, ok := tags.Lookup(key); ok {
+			st.WriteString(fmt.Sprintf(" %s:%s", key, strconv.Quote(v)))
+		}
+	}
+	sf.Tag = reflect.StructTag(st.String())
+	return f, sf, nil
+}
+
+// Resolve the type of a value parsed from a string
+func (sg *staticGen) leafOf(expr ast.Expr) (*genLeaf, error) {
+	name := types.ExprString(expr)
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if t, ok := staticBasicTypes[e.Name]; ok {
+			return &genLeaf{typeName: name, kind: t.Kind(), rtype: t, zero: basicZero(t.Kind())}, nil
+		}
+
+		ts, ok := sg.specs[e.Name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported type %s", name)
+		}
+
+		//The self-parsing types are compiled as strings
+		leaf := &genLeaf{typeName: name, kind: reflect.String, rtype: staticBasicTypes["string"], setter: sg.setterOf(e.Name)}
+		switch u := ts.Type.(type) {
+		case *ast.Ident:
+			t, ok := staticBasicTypes[u.Name]
+			if !ok {
+				return nil, fmt.Errorf("unsupported type %s", name)
+			}
+			leaf.kind, leaf.rtype, leaf.zero = t.Kind(), t, basicZero(t.Kind())
+			return leaf, nil
+		case *ast.StructType:
+			leaf.zero = "%s == (" + name + "{})"
+		case *ast.ArrayType:
+			leaf.zero = "%s == nil"
+			if u.Len != nil {
+				leaf.zero = "%s == (" + name + "{})"
+			}
+		case *ast.MapType, *ast.StarExpr, *ast.FuncType, *ast.ChanType, *ast.InterfaceType:
+			leaf.zero = "%s == nil"
+		}
+
+		if len(leaf.setter) == 0 || len(leaf.zero) == 0 {
+			return nil, fmt.Errorf("unsupported type %s", name)
+		}
+		return leaf, nil
+	case *ast.SelectorExpr:
+		path, ok := sg.imports[getIdentName(e.X)]
+		if !ok {
+			return nil, fmt.Errorf("unsupported type %s", name)
+		}
+
+		if name == "time.Duration" && path == "time" {
+			return &genLeaf{typeName: name, kind: reflect.Int64, rtype: reflect.TypeOf(time.Duration(0)), zero: "%s == 0"}, nil
+		}
+
+		if zero, ok := staticTextTypes[name]; ok {
+			return &genLeaf{typeName: name, kind: reflect.String, rtype: staticBasicTypes["string"], setter: "UnmarshalText", zero: zero}, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported type %s", name)
+}
+
+// The method the type parses itself with
+func (sg *staticGen) setterOf(name string) string {
+	switch {
+	case sg.methods[name]["Set"]:
+		return "Set"
+	case sg.methods[name]["UnmarshalText"]:
+		return "UnmarshalText"
+	}
+	return ""
+}
+
+func basicZero(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return `%s == ""`
+	case reflect.Bool:
+		return "!%s"
+	}
+	return "%s == 0"
+}
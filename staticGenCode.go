@@ -0,0 +1,472 @@
+package screw
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The markers of the help text, they are replaced when the help is printed
+const (
+	helpProcName = "\x00"
+	helpEnvStart = "\x01"
+	helpEnvEnd   = "\x02"
+)
+
+// The imports of the generated runtime
+var staticRuntimeImports = map[string]string{
+	"errors":  "errors",
+	"fmt":     "fmt",
+	"json":    "encoding/json",
+	"os":      "os",
+	"strconv": "strconv",
+	"strings": "strings",
+	"time":    "time",
+	"utf8":    "unicode/utf8",
+}
+
+// The code generation of one structure
+type staticType struct {
+	*staticGen
+	name   string
+	prefix string
+	root   *genStruct
+	spec   *Spec
+	code   strings.Builder
+	//The generated set functions and their names
+	setCode strings.Builder
+	setters map[string]string
+	numbers map[*command]int
+}
+
+// Generate ParseXxx and the code it uses
+func (sg *staticGen) genParse(code *strings.Builder, name string, s *genStruct) error {
+	spec, err := Compile(reflect.New(s.typ).Interface())
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	t := &staticType{staticGen: sg, name: name, prefix: "screw" + name, root: s, spec: spec, setters: make(map[string]string), numbers: make(map[*command]int)}
+
+	fmt.Fprintf(&t.code, `// Parse%[1]s parses args into a new %[1]s the same way as screw.Bind, without reflection.
+// -h and -v print the help and the version information and exit the process
+func Parse%[1]s(args []string) (*%[1]s, error) {
+	x := new(%[1]s)
+	if err := %[2]sDefaults(x); err != nil {
+		return nil, err
+	}
+
+	if err := %[2]sCommand0(x).parse(args); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+`, name, t.prefix)
+
+	if err := t.genDefaults(); err != nil {
+		return err
+	}
+	t.numberCommands(spec.cmd)
+	t.genCommand(spec.cmd)
+
+	code.WriteString(t.code.String())
+	code.WriteString(t.setCode.String())
+	code.WriteString(strings.Replace(staticRuntime, "screwRT", t.prefix, -1))
+	return nil
+}
+
+// Find the field, the expression of the field and the structure that owns it
+func (t *staticType) field(p fieldPath) (f *genField, expr string, owner *genStruct, ownerExpr string) {
+	s, expr := t.root, "x"
+	for _, i := range p.index {
+		owner, ownerExpr = s, expr
+		f = s.fields[i]
+		expr += "." + f.name
+		s = f.sub
+	}
+	return
+}
+
+// The type of the field in the structure the spec is compiled with
+func (t *staticType) fieldType(p fieldPath) reflect.Type {
+	typ := t.root.typ
+	for _, i := range p.index {
+		typ = typ.Field(i).Type
+	}
+	return typ
+}
+
+func (t *staticType) genDefaults() error {
+	fmt.Fprintf(&t.code, "func %sDefaults(x *%s) error {\n", t.prefix, t.name)
+	for _, d := range t.spec.cmd.defaults {
+		f, expr, _, _ := t.field(d.field)
+
+		//The JSON defaults are decoded now, they are assigned as literals
+		if def := []byte(d.value); isDefvalJSON(def) {
+			if f.kind != reflect.Slice && f.kind != reflect.Map || len(f.leaf.setter) > 0 {
+				fmt.Fprintf(&t.code, "if err := json.Unmarshal([]byte(%s), &%s); err != nil {\nreturn err\n}\n", strconv.Quote(d.value), expr)
+				continue
+			}
+
+			v := reflect.New(t.fieldType(d.field))
+			if err := json.Unmarshal(def, v.Interface()); err != nil {
+				return err
+			}
+			fmt.Fprintf(&t.code, "%s = %s\n", expr, literal(v.Elem(), f))
+			continue
+		}
+
+		//The same as setDefaultValue, the default of a map without sep is split strictly by comma
+		sep := d.sep
+		if len(sep) == 0 && f.kind == reflect.Map {
+			sep = defaultSep
+		}
+		fmt.Fprintf(&t.code, "if err := %s(x, %s, %s); err != nil {\nreturn err\n}\n",
+			t.setter(d.field, false), strconv.Quote(d.value), strconv.Quote(sep))
+	}
+	t.code.WriteString("return nil\n}\n\n")
+	return nil
+}
+
+// The Go literal of the slice or the map
+func literal(v reflect.Value, f *genField) string {
+	if v.IsNil() {
+		return "nil"
+	}
+
+	var elems []string
+	switch f.kind {
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			elems = append(elems, leafLiteral(v.Index(i), f.leaf))
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			elems = append(elems, leafLiteral(k, f.key)+": "+leafLiteral(v.MapIndex(k), f.leaf))
+		}
+		sort.Strings(elems)
+	}
+	return f.typeName + "{" + strings.Join(elems, ", ") + "}"
+}
+
+func leafLiteral(v reflect.Value, leaf *genLeaf) string {
+	s := fmt.Sprintf("%#v", v.Interface())
+	if _, ok := staticBasicTypes[leaf.typeName]; !ok {
+		s = leaf.typeName + "(" + s + ")"
+	}
+	return s
+}
+
+// Generate the function that sets the value to the field, the same as setSepValue.
+// The map of the once option can not set a key twice
+func (t *staticType) setter(p fieldPath, once bool) string {
+	f, expr, _, _ := t.field(p)
+	once = once && f.kind == reflect.Map
+
+	key := fmt.Sprint(p.root, p.index, once)
+	if name, ok := t.setters[key]; ok {
+		return name
+	}
+
+	name := fmt.Sprintf("%sSet%d", t.prefix, len(t.setters))
+	t.setters[key] = name
+
+	var code strings.Builder
+	fmt.Fprintf(&code, "func %s(x *%s, val, sep string) error {\n", name, t.name)
+	switch f.kind {
+	case reflect.Slice:
+		fmt.Fprintf(&code, "add := func(val string) error {\nvar e %s\n%s%s = append(%[3]s, e)\nreturn nil\n}\n\n", f.leaf.typeName, t.parseLeaf(f.leaf, "e", "val"), expr)
+		code.WriteString("if len(sep) == 0 {\nreturn add(val)\n}\n\n")
+		code.WriteString("for _, val := range strings.Split(val, sep) {\nif err := add(val); err != nil {\nreturn err\n}\n}\nreturn nil\n")
+	case reflect.Map:
+		fmt.Fprintf(&code, "if strings.HasPrefix(strings.TrimSpace(val), \"{\") {\nreturn json.Unmarshal([]byte(val), &%s)\n}\n\n", expr)
+		fmt.Fprintf(&code, "pairs := %sMapPairs(val, sep)\n", t.prefix)
+		fmt.Fprintf(&code, "if %s == nil {\n%[1]s = make(%s)\n}\n\n", expr, f.typeName)
+		code.WriteString("for _, pair := range pairs {\nif len(pair) == 0 {\ncontinue\n}\n\n")
+		code.WriteString("pos := strings.IndexByte(pair, '=')\nif pos == -1 {\nreturn fmt.Errorf(\"invalid map value (%s), want key=value\", pair)\n}\n\n")
+		fmt.Fprintf(&code, "var k %s\n%s", f.key.typeName, t.parseLeaf(f.key, "k", "pair[:pos]"))
+		if once {
+			fmt.Fprintf(&code, "if _, ok := %s[k]; ok {\nreturn fmt.Errorf(`error: The key '%%s' was provided more than once, but cannot be used multiple times`, pair[:pos])\n}\n", expr)
+		}
+		fmt.Fprintf(&code, "var v %s\n%s%s[k] = v\n}\nreturn nil\n", f.leaf.typeName, t.parseLeaf(f.leaf, "v", "pair[pos+1:]"), expr)
+	default:
+		fmt.Fprintf(&code, "%sreturn nil\n", t.parseLeaf(f.leaf, expr, "val"))
+	}
+	code.WriteString("}\n\n")
+
+	t.setCode.WriteString(code.String())
+	return name
+}
+
+// The statements that parse src into dst, the same as setBase
+func (t *staticType) parseLeaf(leaf *genLeaf, dst, src string) string {
+	switch leaf.setter {
+	case "Set":
+		return fmt.Sprintf("if err := %s.Set(%s); err != nil {\nreturn err\n}\n", dst, src)
+	case "UnmarshalText":
+		return fmt.Sprintf("if err := %s.UnmarshalText([]byte(%s)); err != nil {\nreturn err\n}\n", dst, src)
+	}
+
+	var fn string
+	switch leaf.kind {
+	case reflect.String:
+		if leaf.typeName == "string" {
+			return fmt.Sprintf("%s = %s\n", dst, src)
+		}
+		return fmt.Sprintf("%s = %s(%s)\n", dst, leaf.typeName, src)
+	case reflect.Bool:
+		fn = fmt.Sprintf("%sParseBool(%s)", t.prefix, src)
+	case reflect.Float32, reflect.Float64:
+		fn = fmt.Sprintf("%sParseFloat(%s, %d)", t.prefix, src, convertFunc[leaf.kind].bitSize)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		fn = fmt.Sprintf("%sParseUint(%s, %d)", t.prefix, src, convertFunc[leaf.kind].bitSize)
+	default:
+		fn = fmt.Sprintf("%sParseInt(%s, %d)", t.prefix, src, convertFunc[leaf.kind].bitSize)
+		if leaf.typeName == "time.Duration" {
+			fn = fmt.Sprintf("%sParseDuration(%s)", t.prefix, src)
+		}
+	}
+	return fmt.Sprintf("{\nn, err := %s\nif err != nil {\nreturn err\n}\n%s = %s(n)\n}\n", fn, dst, leaf.typeName)
+}
+
+// Generate the function that creates the command and the functions of its subcommands
+func (t *staticType) genCommand(c *command) {
+	var code strings.Builder
+	fmt.Fprintf(&code, "func %sCommand%d(x *%s) *%[1]sCommand {\n", t.prefix, t.numbers[c], t.name)
+
+	fmt.Fprintf(&code, "c := &%sCommand{version: %s, help: %s}\n", t.prefix, strconv.Quote(c.version), strconv.Quote(t.help(c)))
+
+	//The options are created in the order of the registration
+	var options []*Option
+	seen := make(map[*Option]bool)
+	for _, o := range c.shortAndLong {
+		if !seen[o] {
+			seen[o] = true
+			options = append(options, o)
+		}
+	}
+	for _, o := range c.envAndArgs {
+		if !seen[o] {
+			seen[o] = true
+			options = append(options, o)
+		}
+	}
+	sort.Slice(options, func(i, j int) bool { return options[i].id < options[j].id })
+
+	for _, o := range options {
+		fmt.Fprintf(&code, "o%d := %s\n", o.id, t.option(o))
+	}
+
+	if len(c.shortAndLong) > 0 {
+		var names []string
+		for name := range c.shortAndLong {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		fmt.Fprintf(&code, "c.shortAndLong = map[string]*%sOption{\n", t.prefix)
+		for _, name := range names {
+			fmt.Fprintf(&code, "%s: o%d,\n", strconv.Quote(name), c.shortAndLong[name].id)
+		}
+		code.WriteString("}\n")
+	}
+
+	if len(c.envAndArgs) > 0 {
+		var ids []string
+		for _, o := range c.envAndArgs {
+			ids = append(ids, fmt.Sprintf("o%d", o.id))
+		}
+		fmt.Fprintf(&code, "c.envAndArgs = []*%sOption{%s}\n", t.prefix, strings.Join(ids, ", "))
+	}
+
+	var subs []string
+	for name := range c.subcommand {
+		subs = append(subs, name)
+	}
+	sort.Strings(subs)
+
+	if len(subs) > 0 {
+		fmt.Fprintf(&code, "c.subcommand = map[string]*%sCommand{\n", t.prefix)
+		for _, name := range subs {
+			fmt.Fprintf(&code, "%s: %sCommand%d(x),\n", strconv.Quote(name), t.prefix, t.numbers[c.subcommand[name].command])
+		}
+		code.WriteString("}\n")
+	}
+
+	//SubMain is called after the subcommand is parsed
+	if c.parent != nil {
+		if f, expr, _, _ := t.field(c.field); t.methods[f.sub.name][defaultSubMain] {
+			fmt.Fprintf(&code, "c.subMain = %s.%s\n", expr, defaultSubMain)
+		}
+	}
+	code.WriteString("return c\n}\n\n")
+
+	t.code.WriteString(code.String())
+
+	for _, name := range subs {
+		t.genCommand(c.subcommand[name].command)
+	}
+}
+
+// Number the commands in the depth-first order
+func (t *staticType) numberCommands(c *command) {
+	t.numbers[c] = len(t.numbers)
+
+	var subs []string
+	for name := range c.subcommand {
+		subs = append(subs, name)
+	}
+	sort.Strings(subs)
+
+	for _, name := range subs {
+		t.numberCommands(c.subcommand[name].command)
+	}
+}
+
+// The literal of the option
+func (t *staticType) option(o *Option) string {
+	f, expr, _, ownerExpr := t.field(o.field)
+
+	var fields []string
+	add := func(format string, a ...interface{}) {
+		fields = append(fields, fmt.Sprintf(format, a...))
+	}
+
+	add("name: %s", strconv.Quote(o.showName()))
+	if len(o.envName) > 0 {
+		add("env: %s", strconv.Quote(o.envName))
+	}
+	if len(o.argsName) > 0 {
+		add("args: %s", strconv.Quote(o.argsName))
+	}
+	if len(o.sep) > 0 {
+		add("sep: %s", strconv.Quote(o.sep))
+	}
+	if len(o.choices) > 0 {
+		var choices []string
+		for _, c := range o.choices {
+			choices = append(choices, strconv.Quote(c))
+		}
+		add("choices: []string{%s}", strings.Join(choices, ", "))
+	}
+	if o.ignoreCase {
+		add("ignoreCase: true")
+	}
+
+	//The bool checks of setBoolAndBoolSliceDefval, parseShort and setEnvAndArgs
+	if f.kind == reflect.Bool || f.kind == reflect.Slice && f.leaf.typeName == "bool" {
+		add("isBool: true")
+	}
+	if f.typeName == "bool" || f.typeName == "[]bool" {
+		add("exactBool: true")
+	}
+	if f.kind == reflect.Bool {
+		add("kindBool: true")
+	}
+	if f.kind == reflect.Slice {
+		add("isSlice: true")
+	}
+	if f.kind == reflect.Map {
+		add("isMap: true")
+	}
+	if o.greedy {
+		add("greedy: true")
+	}
+	if o.once {
+		add("once: true")
+	}
+	if len(o.showDefValue) > 0 {
+		add("hasDefault: true")
+	}
+
+	if len(f.callback) > 0 {
+		add("set: func(val, sep string) error {\n%s.%s(val)\nreturn nil\n}", ownerExpr, f.callback)
+	} else {
+		add("set: func(val, sep string) error {\nreturn %s(x, val, sep)\n}", t.setter(o.field, o.once))
+	}
+
+	zero := fmt.Sprintf("%s == nil", expr)
+	if f.kind != reflect.Slice && f.kind != reflect.Map {
+		zero = fmt.Sprintf(f.leaf.zero, expr)
+	}
+	add("isZero: func() bool {\nreturn %s\n}", zero)
+	add("reset: func() {\nvar z %s\n%s = z\n}", f.typeName, expr)
+
+	return fmt.Sprintf("&%sOption{\n%s,\n}", t.prefix, strings.Join(fields, ",\n"))
+}
+
+// Render the help message, the process name and the env values are filled in when it is printed
+func (t *staticType) help(c *command) string {
+	h := Help{}
+	c.genHelpMessage(&h, func(string) (string, bool) { return "", false })
+
+	opts := [][]showOption{h.Flags, h.Options, h.Args}
+	for _, g := range h.Groups {
+		opts = append(opts, g.Options)
+	}
+	for _, opts := range opts {
+		for i := range opts {
+			if env := opts[i].Env; len(env) > 0 {
+				if pos := strings.IndexByte(env, '='); pos != -1 {
+					env = env[:pos]
+				}
+				opts[i].Env = helpEnvStart + env + helpEnvEnd
+			}
+		}
+	}
+
+	if c.parent == nil {
+		h.ProcessName = helpProcName
+	}
+
+	var b bytes.Buffer
+	if err := h.output(&b); err != nil {
+		panic(err)
+	}
+	return b.String()
+}
+
+// Write the file with the imports the code uses
+func (sg *staticGen) genFile(code string) ([]byte, error) {
+	//The package names of the selectors are the imports
+	f, err := parser.ParseFile(token.NewFileSet(), "", "package p\n"+code, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]string)
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok {
+				if path, ok := sg.imports[x.Name]; ok {
+					paths[path] = x.Name
+				} else if path, ok := staticRuntimeImports[x.Name]; ok {
+					paths[path] = x.Name
+				}
+			}
+		}
+		return true
+	})
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "%s\n\npackage %s\n\nimport (\n", staticGenHeader, sg.pkgName)
+	for path, name := range paths {
+		if name != importName(path) {
+			out.WriteString(name + " ")
+		}
+		out.WriteString(strconv.Quote(path) + "\n")
+	}
+	out.WriteString(")\n\n")
+	out.WriteString(code)
+
+	return format.Source(out.Bytes())
+}
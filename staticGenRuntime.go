@@ -0,0 +1,549 @@
+package screw
+
+// The parser of the generated code, it follows cmdParser without reflection.
+// screwRT is replaced with the prefix of the structure. Test_StaticGen_Parity runs the same arguments
+// through the generated code and Bind, a change of cmdParser needs a case there
+const staticRuntime = `type screwRTOption struct {
+	name       string
+	env        string
+	args       string
+	sep        string
+	choices    []string
+	ignoreCase bool
+	//The kind of the field, isBool is also set for []bool and exactBool only for bool and []bool
+	isBool     bool
+	exactBool  bool
+	kindBool   bool
+	isSlice    bool
+	isMap      bool
+	greedy     bool
+	once       bool
+	hasDefault bool
+	cmdSet     bool
+	set        func(val, sep string) error
+	isZero     func() bool
+	reset      func()
+}
+
+type screwRTCommand struct {
+	shortAndLong map[string]*screwRTOption
+	envAndArgs   []*screwRTOption
+	subcommand   map[string]*screwRTCommand
+	subMain      func()
+	version      string
+	help         string
+	args         []string
+	unparsedArgs []string
+}
+
+func (o *screwRTOption) setValue(val, sep string) (err error) {
+	if val, err = o.checkChoices(val, sep); err != nil {
+		return err
+	}
+
+	if o.hasDefault && !o.isZero() && !o.cmdSet {
+		o.reset()
+	}
+	o.cmdSet = true
+	return o.set(val, sep)
+}
+
+func (o *screwRTOption) checkOnce(name string) error {
+	if o.once && !o.isZero() && !o.isMap {
+		return fmt.Errorf("error: The argument '%s' was provided more than once, but cannot be used multiple times", name)
+	}
+	return nil
+}
+
+func (o *screwRTOption) checkChoices(val, sep string) (string, error) {
+	if len(o.choices) == 0 {
+		return val, nil
+	}
+
+	if o.isSlice && len(sep) > 0 {
+		var err error
+		elems := strings.Split(val, sep)
+		for i, elem := range elems {
+			if elems[i], err = o.checkChoice(elem); err != nil {
+				return "", err
+			}
+		}
+		return strings.Join(elems, sep), nil
+	}
+
+	return o.checkChoice(val)
+}
+
+func (o *screwRTOption) checkChoice(val string) (string, error) {
+	for _, choice := range o.choices {
+		if choice == val || o.ignoreCase && strings.EqualFold(choice, val) {
+			return choice, nil
+		}
+	}
+
+	m := fmt.Sprintf("error: invalid value '%s' for %s, expected one of %s", val, o.name, strings.Join(o.choices, ", "))
+	if s := screwRTBestMatch(val, o.choices, o.ignoreCase); len(s) > 0 {
+		m += fmt.Sprintf("\n	Did you mean '%s'?\n", s)
+	}
+	return "", errors.New(m)
+}
+
+func (c *screwRTCommand) parse(args []string) error {
+	c.args = args
+	for i := 0; i < len(c.args); i++ {
+		if err := c.parseOneOption(&i); err != nil {
+			return err
+		}
+	}
+
+	for _, o := range c.envAndArgs {
+		if err := c.setEnvAndArgs(o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *screwRTCommand) parseOneOption(index *int) error {
+	arg := c.args[*index]
+	if len(arg) == 0 {
+		return errors.New("fail option")
+	}
+
+	if arg[0] != '-' {
+		if sub, ok := c.subcommand[arg]; ok {
+			args := c.args[*index+1:]
+			c.args = c.args[0:0]
+			if err := sub.parse(args); err != nil {
+				return err
+			}
+			if sub.subMain != nil {
+				sub.subMain()
+			}
+			return nil
+		}
+
+		if len(c.subcommand) > 0 && len(c.envAndArgs) == 0 {
+			return fmt.Errorf("Unknown subcommand:%s", arg)
+		}
+
+		c.unparsedArgs = append(c.unparsedArgs, arg)
+		return nil
+	}
+
+	if arg == "-" {
+		c.unparsedArgs = append(c.unparsedArgs, arg)
+		return nil
+	}
+
+	numMinuses := 1
+	if arg[1] == '-' {
+		numMinuses++
+	}
+
+	arg = arg[numMinuses:]
+	if arg == "h" || arg == "help" {
+		if _, ok := c.shortAndLong[arg]; !ok {
+			fmt.Fprint(os.Stdout, screwRTHelp(c.help))
+			os.Exit(0)
+		}
+	}
+
+	if arg == "v" || arg == "version" {
+		if _, ok := c.shortAndLong[arg]; !ok {
+			fmt.Fprintln(os.Stdout, c.version)
+			os.Exit(0)
+		}
+	}
+
+	if numMinuses == 2 {
+		return c.parseLong(arg, index)
+	}
+	return c.parseShort(arg, index)
+}
+
+func (c *screwRTCommand) parseLong(arg string, index *int) error {
+	value := ""
+	o := c.shortAndLong[arg]
+	if o == nil {
+		pos := strings.Index(arg, "=")
+		if pos == -1 {
+			return c.unknownOptionError(arg)
+		}
+
+		if o = c.shortAndLong[arg[:pos]]; o == nil {
+			return c.unknownOptionError(arg)
+		}
+		value = arg[pos+1:]
+	}
+
+	if len(arg) == 1 {
+		return c.unknownOptionError(arg)
+	}
+
+	if value == "" && o.isBool {
+		value = "true"
+	}
+
+	if len(value) > 0 {
+		if err := o.checkOnce("--" + strings.SplitN(arg, "=", 2)[0]); err != nil {
+			return err
+		}
+		return o.setValue(value, o.sep)
+	}
+
+	for {
+		(*index)++
+		if *index >= len(c.args) {
+			return nil
+		}
+
+		value = c.args[*index]
+		if c.findFallbackOpt(value, index) {
+			return nil
+		}
+
+		if err := o.checkOnce("--" + arg); err != nil {
+			return err
+		}
+
+		if err := o.setValue(value, o.sep); err != nil {
+			return err
+		}
+
+		if !o.greedy {
+			return nil
+		}
+	}
+}
+
+func (c *screwRTCommand) parseShort(arg string, index *int) error {
+	var (
+		o          *screwRTOption
+		shortIndex int
+		a          rune
+	)
+
+	find := false
+	for shortIndex, a = range arg {
+		if a >= utf8.RuneSelf {
+			return errors.New("Illegal character set")
+		}
+
+		optionName := string(byte(a))
+		if o = c.shortAndLong[optionName]; o == nil {
+			return c.unknownOptionErrorShort(optionName, arg)
+		}
+
+		find = true
+		findEqual := false
+		value := arg
+		if !o.exactBool {
+			shortIndex++
+		}
+
+		if o.isMap {
+			if len(value[shortIndex:]) > 0 {
+				findEqual = true
+				if value[shortIndex] == '=' {
+					shortIndex++
+				}
+			}
+		} else if len(value[shortIndex:]) > 0 && len(value[shortIndex+1:]) > 0 {
+			if value[shortIndex:][0] == '=' {
+				findEqual = true
+				shortIndex++
+			}
+
+			if len(value[shortIndex+1:]) > 0 && value[shortIndex+1:][0] == '=' {
+				findEqual = true
+				shortIndex += 2
+			}
+		}
+
+	getchar:
+		for value := arg; ; {
+			if len(value[shortIndex:]) > 0 {
+				val := value[shortIndex:]
+				if o.exactBool {
+					val = "true"
+				}
+
+				if findEqual {
+					val = value[shortIndex:]
+				}
+
+				if err := o.checkOnce("-" + optionName); err != nil {
+					return err
+				}
+
+				if err := o.setValue(val, o.sep); err != nil {
+					return err
+				}
+
+				if findEqual {
+					return nil
+				}
+
+				if o.exactBool {
+					break getchar
+				}
+
+				if !o.greedy {
+					return nil
+				}
+			}
+
+			shortIndex = 0
+			if *index+1 >= len(c.args) {
+				return nil
+			}
+			(*index)++
+
+			value = c.args[*index]
+			if c.findFallbackOpt(value, index) {
+				return nil
+			}
+		}
+	}
+
+	if find {
+		return nil
+	}
+	return c.unknownOptionErrorShort(arg, arg)
+}
+
+// Roll back the option that follows the option waiting for a value
+func (c *screwRTCommand) findFallbackOpt(value string, index *int) bool {
+	if !strings.HasPrefix(value, "-") {
+		return false
+	}
+
+	num := 1
+	if len(value) > 1 && value[1] == '-' {
+		num++
+	}
+
+	end := len(value)
+	if e := strings.IndexByte(value, '='); e != -1 {
+		end = e
+	}
+
+	_, ok := c.shortAndLong[value[num:end]]
+	//The short option can be followed by its value, such as -Dkey=value or -p8080
+	if !ok && num == 1 && end > 1 {
+		_, ok = c.shortAndLong[value[1:2]]
+	}
+
+	if ok {
+		(*index)--
+	}
+	return ok
+}
+
+func (c *screwRTCommand) setEnvAndArgs(o *screwRTOption) error {
+	if len(o.env) > 0 {
+		if v, ok := os.LookupEnv(o.env); ok {
+			if o.kindBool && v != "false" {
+				v = "true"
+			}
+
+			if o.cmdSet && (o.isSlice || o.isMap) {
+				o.reset()
+			}
+
+			sep := o.sep
+			if len(sep) == 0 {
+				sep = ","
+			}
+			return o.setValue(v, sep)
+		}
+	}
+
+	if len(o.args) == 0 || len(c.unparsedArgs) == 0 {
+		return nil
+	}
+
+	if o.isSlice {
+		for _, arg := range c.unparsedArgs {
+			if err := o.setValue(arg, o.sep); err != nil {
+				return err
+			}
+		}
+		c.unparsedArgs = nil
+		return nil
+	}
+
+	if err := o.setValue(c.unparsedArgs[0], o.sep); err != nil {
+		return err
+	}
+	c.unparsedArgs = c.unparsedArgs[1:]
+	return nil
+}
+
+func (c *screwRTCommand) unknownOptionErrorShort(optionName string, arg string) error {
+	m := fmt.Sprintf("error: Found argument '-%s' which wasn't expected, or isn't valid in this context", optionName)
+	return errors.New(m + c.maybeHelpMsg(arg))
+}
+
+func (c *screwRTCommand) unknownOptionError(optionName string) error {
+	m := fmt.Sprintf("error: Found argument '--%s' which wasn't expected, or isn't valid in this context", optionName)
+	return errors.New(m + c.maybeHelpMsg(optionName))
+}
+
+func (c *screwRTCommand) maybeHelpMsg(optionName string) string {
+	opts := make([]string, 0, len(c.shortAndLong))
+	for k := range c.shortAndLong {
+		opts = append(opts, k)
+	}
+
+	if s := screwRTBestMatch(optionName, opts, false); len(s) > 0 {
+		return fmt.Sprintf("\n	Did you mean --%s?\n", s)
+	}
+
+	if _, ok := c.subcommand[optionName]; ok {
+		return fmt.Sprintf("\n	Did you mean '%s' subcommand?\n", optionName)
+	}
+	return ""
+}
+
+// The most similar target by the edit distance, empty if none is similar
+func screwRTBestMatch(s string, targets []string, ignoreCase bool) string {
+	best, bestScore := "", 0.0
+	for i, target := range targets {
+		s1, s2 := s, target
+		if ignoreCase {
+			s1, s2 = strings.ToLower(s1), strings.ToLower(s2)
+		}
+
+		score := 0.0
+		switch {
+		case s1 == s2:
+			score = 1.0
+		case len(s1) > 0 && len(s2) > 0:
+			r1, r2 := []rune(s1), []rune(s2)
+			dist := make([]int, len(r2)+1)
+			for x := range dist {
+				dist[x] = x
+			}
+
+			for y := 1; y <= len(r1); y++ {
+				prev := dist[0]
+				dist[0] = y
+				for x := 1; x <= len(r2); x++ {
+					cost := 1
+					if r1[y-1] == r2[x-1] {
+						cost = 0
+					}
+
+					cur := dist[x]
+					dist[x] = prev + cost
+					if dist[x-1]+1 < dist[x] {
+						dist[x] = dist[x-1] + 1
+					}
+					if cur+1 < dist[x] {
+						dist[x] = cur + 1
+					}
+					prev = cur
+				}
+			}
+
+			max := len(r1)
+			if len(r2) > max {
+				max = len(r2)
+			}
+			score = 1.0 - float64(dist[len(r2)])/float64(max)
+		}
+
+		if i == 0 || score > bestScore {
+			best, bestScore = target, score
+		}
+	}
+
+	if bestScore > 0.0 {
+		return best
+	}
+	return ""
+}
+
+// Fill in the process name and the env values of the help message
+func screwRTHelp(help string) string {
+	var b strings.Builder
+	for {
+		i := strings.IndexAny(help, "\x00\x01")
+		if i == -1 {
+			break
+		}
+
+		b.WriteString(help[:i])
+		if help[i] == 0 {
+			b.WriteString(os.Args[0])
+			help = help[i+1:]
+			continue
+		}
+
+		end := i + strings.IndexByte(help[i:], 2)
+		name := help[i+1 : end]
+		b.WriteString(name)
+		if v := os.Getenv(name); len(v) > 0 {
+			b.WriteString("=" + v)
+		}
+		help = help[end+1:]
+	}
+
+	b.WriteString(help)
+	return b.String()
+}
+
+func screwRTParseInt(val string, bitSize int) (int64, error) {
+	if val == "" {
+		val = "0"
+	}
+	return strconv.ParseInt(val, 10, bitSize)
+}
+
+func screwRTParseUint(val string, bitSize int) (uint64, error) {
+	if val == "" {
+		val = "0"
+	}
+	return strconv.ParseUint(val, 10, bitSize)
+}
+
+func screwRTParseFloat(val string, bitSize int) (float64, error) {
+	if val == "" {
+		val = "0.0"
+	}
+	return strconv.ParseFloat(val, bitSize)
+}
+
+func screwRTParseBool(val string) (bool, error) {
+	if val == "" {
+		val = "false"
+	}
+	return strconv.ParseBool(val)
+}
+
+func screwRTParseDuration(val string) (time.Duration, error) {
+	if val == "" {
+		val = "0"
+	}
+	return time.ParseDuration(val)
+}
+
+func screwRTMapPairs(val string, sep string) []string {
+	if len(sep) > 0 {
+		return strings.Split(val, sep)
+	}
+
+	var pairs []string
+	for _, part := range strings.Split(val, ",") {
+		if len(pairs) > 0 && strings.IndexByte(part, '=') == -1 {
+			pairs[len(pairs)-1] += "," + part
+			continue
+		}
+		pairs = append(pairs, part)
+	}
+	return pairs
+}
+
+`
@@ -0,0 +1,190 @@
+package screw
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The structures of the parity test, the generated parser and Bind must agree on them
+const staticGenTypes = `package main
+
+import (
+	"strings"
+	"time"
+)
+
+type Level int
+
+func (l *Level) Set(s string) error {
+	switch strings.ToLower(s) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return fmt.Errorf("bad level %s", s)
+	}
+	return nil
+}
+
+type TLS struct {
+	Cert     string ` + "`" + `screw:"--cert;env" usage:"certificate file"` + "`" + `
+	Insecure bool   ` + "`" + `screw:"--insecure" usage:"skip verification"` + "`" + `
+}
+
+type Serve struct {
+	Port  int           ` + "`" + `screw:"-p;--port;env=PORT" default:"8080" usage:"port"` + "`" + `
+	Hosts []string      ` + "`" + `screw:"-H;--host;greedy" usage:"hosts"` + "`" + `
+	Wait  time.Duration ` + "`" + `screw:"--wait" default:"1s" usage:"wait"` + "`" + `
+	Dirs  []string      ` + "`" + `screw:"args=dirs" usage:"dirs"` + "`" + `
+}
+
+type Options struct {
+	Debug   bool              ` + "`" + `screw:"-d;--debug" usage:"debug"` + "`" + `
+	Verbose []bool            ` + "`" + `screw:"-v" usage:"verbose"` + "`" + `
+	Name    string            ` + "`" + `screw:"-n;--name;once" default:"app" usage:"name"` + "`" + `
+	Format  string            ` + "`" + `screw:"-f;--format" choices:"json,yaml;ignorecase" default:"json" usage:"format"` + "`" + `
+	Tags    []string          ` + "`" + `screw:"-t;--tag" sep:"," usage:"tags"` + "`" + `
+	Labels  map[string]int    ` + "`" + `screw:"-L;--label" usage:"labels"` + "`" + `
+	Ratio   float64           ` + "`" + `screw:"--ratio" usage:"ratio"` + "`" + `
+	Level   Level             ` + "`" + `screw:"--level" usage:"level"` + "`" + `
+	Logs    []string
+	Trace   string            ` + "`" + `screw:"--trace;callback=AddLog" usage:"trace"` + "`" + `
+	Up      TLS               ` + "`" + `prefix:"up-"` + "`" + `
+	Serve   Serve             ` + "`" + `screw:"subcommand=serve" usage:"serve"` + "`" + `
+	Files   []string          ` + "`" + `screw:"args=files" usage:"files"` + "`" + `
+}
+
+func (o *Options) AddLog(val string) {
+	o.Logs = append(o.Logs, val)
+}
+`
+
+// Compare the results and the errors of ParseOptions and Bind for every case in os.Args[1]
+const staticGenMain = `package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+
+	"github.com/RainFallsSilent/screw"
+)
+
+func main() {
+	var cases [][]string
+	if err := json.Unmarshal([]byte(os.Args[1]), &cases); err != nil {
+		panic(err)
+	}
+
+	for _, args := range cases {
+		got, err := ParseOptions(args)
+		need := new(Options)
+		err2 := screw.New(args).SetExit(false).SetOutput(ioutil.Discard).Bind(need)
+		switch {
+		case err != nil || err2 != nil:
+			if fmt.Sprint(err) != fmt.Sprint(err2) {
+				fmt.Printf("%q: got error %v, need %v\n", args, err, err2)
+			}
+		case !reflect.DeepEqual(got, need):
+			fmt.Printf("%q: got %+v, need %+v\n", args, got, need)
+		}
+	}
+}
+`
+
+func Test_StaticGen_Parity(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a program")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not found")
+	}
+
+	root, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	sum, err := ioutil.ReadFile(filepath.Join(root, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mod := "module screwgentest\n\ngo 1.13\n\nrequire github.com/RainFallsSilent/screw v0.0.0\n\nreplace github.com/RainFallsSilent/screw => " + root + "\n"
+	types := strings.Replace(staticGenTypes, "import (\n", "import (\n\t\"fmt\"\n", 1)
+	for name, data := range map[string]string{"go.mod": mod, "go.sum": string(sum), "types.go": types, "main.go": staticGenMain} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	code, err := NewStaticGen(dir).Type("Options").Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "options_screw.go"), code, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := [][]string{
+		{},
+		{"-d", "-vvv", "--name", "x", "a", "b"},
+		{"-n", "x", "-n", "y"},
+		{"--name=x", "-f", "YAML", "-t", "a,b", "--tag", "c"},
+		{"-f", "xml"},
+		{"-L", "a=1", "--label", "b=2,c=3"},
+		{"-L", "a=x"},
+		{"--ratio", "0.5", "--level", "HIGH"},
+		{"--level", "mid"},
+		{"--trace", "a", "--trace=b"},
+		{"--up-cert", "c.pem", "--up-insecure"},
+		{"--cert", "c.pem"},
+		{"serve", "-p", "1", "-H", "a", "b", "--wait", "2m", "d1", "d2"},
+		{"-d", "serve", "--port=x"},
+		{"--unknown"},
+		{"-x"},
+		{"serve", "--debug"},
+		{"--ratio"},
+		{"f1", "--name", "x", "f2"},
+		//The suggestions and the choices are checked by the copy of the runtime too
+		{"--nam", "x"},
+		{"--formt", "json"},
+		{"--up-cer", "c.pem"},
+		{"--serve"},
+		{"serve", "--prot", "1"},
+		{"-f", "jsn"},
+		{"-f", "Yml"},
+		{"--format=YAML", "-f", "json"},
+		{"-n", "x", "--name=y"},
+		{"-dv", "-n"},
+		{"serve", "-p"},
+		{"--label", `{"a":1}`, "-La=2"},
+		{"-t", "a", "--", "-x", "serve"},
+	}
+	data, err := json.Marshal(cases)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(goBin, "run", ".", string(data))
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "PORT=9090", "UP_CERT=env.pem")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+
+	if len(out) > 0 {
+		t.Errorf("the generated parser differs from Bind:\n%s", out)
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+var (
+	port    = flag.Int("port", 8080, "port")
+	verbose bool
+	name    string
+)
+
+func init() {
+	flag.BoolVar(&verbose, "v", false, "verbose")
+	flag.StringVar(&name, "name", "x", "name")
+}
+
+func main() {
+	flag.Parse()
+	fmt.Println(*port, verbose, name)
+}
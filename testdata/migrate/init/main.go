@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+// register the options
+func init() {
+	flag.IntVar(&retries, "retries", 3, "retries")
+	fmt.Println("init")
+}
+
+var retries int
+
+// only flags here
+func init() {
+	// the timeout
+	flag.Duration("timeout", 0, "timeout")
+}
+
+func main() {
+	var (
+		lvl = level(3)
+		n   = 1
+	)
+	flag.Var(&lvl, "lvl", "level")
+	flag.Parse()
+	fmt.Println(lvl, n, retries)
+}
+
+type level int
+
+func (l *level) Set(s string) error { return nil }
+func (l *level) String() string     { return "" }
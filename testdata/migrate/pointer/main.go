@@ -0,0 +1,17 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+var dir = flag.String("dir", os.TempDir(), "the dir")
+
+func main() {
+	verbose := flag.Bool("v", false, "verbose")
+	flag.Parse()
+	if *verbose {
+		fmt.Println(*dir, flag.Args(), flag.NArg())
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"os"
+)
+
+func main() {
+	flag.Bool("v", false, "verbose")
+	register(flag.CommandLine, "x")
+	flag.Parse()
+
+	serve := flag.NewFlagSet("serve", flag.ExitOnError)
+	serve.String("addr", ":80", "address")
+	register(serve, "y")
+	serve.Int("workers", 4, "workers")
+	serve.Parse(os.Args[1:])
+}
+
+// The flags are added to the flag sets passed to the function
+func register(fs *flag.FlagSet, _ string) {
+	fs.Duration("timeout", 0, "timeout")
+	fs.String("name", "app", "name")
+}
+
+type server struct{}
+
+// The methods are not resolved
+func (s *server) flags(fs *flag.FlagSet) {
+	fs.Int("port", 8080, "port")
+	fs.Bool("d", false, "debug")
+}
+
+var unused = func(set *flag.FlagSet) {
+	set.String("level", "info", "level")
+}
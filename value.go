@@ -0,0 +1,270 @@
+package screw
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// The layout tag is only used by time.Time and []time.Time
+func checkLayout(layout string, t reflect.Type) error {
+	if len(layout) == 0 || t == timeType || t.Kind() == reflect.Slice && t.Elem() == timeType {
+		return nil
+	}
+	return fmt.Errorf("%w: the layout tag needs time.Time or []time.Time, got %s", ErrUnsupportedType, t)
+}
+
+// Set the value like setSepValue, the times are parsed with the layout instead of RFC3339
+func setLayoutValue(val string, sep string, layout string, value reflect.Value) error {
+	if len(layout) == 0 {
+		return setSepValue(val, sep, value)
+	}
+
+	vals := []string{val}
+	if value.Kind() == reflect.Slice && len(sep) > 0 {
+		vals = strings.Split(val, sep)
+	}
+
+	for _, v := range vals {
+		t, err := time.Parse(layout, v)
+		if err != nil {
+			return err
+		}
+
+		if value.Kind() == reflect.Slice {
+			value.Set(reflect.Append(value, reflect.ValueOf(t)))
+			continue
+		}
+		value.Set(reflect.ValueOf(t))
+	}
+	return nil
+}
+
+// The JSON array of strings sets the elements one by one, such as ["1s","1m"] for []time.Duration
+func setJSONStrings(def []byte, layout string, value reflect.Value) (bool, error) {
+	var elems []string
+	if value.Kind() != reflect.Slice || json.Unmarshal(def, &elems) != nil {
+		return false, nil
+	}
+
+	value.Set(reflect.MakeSlice(value.Type(), 0, len(elems)))
+	for _, elem := range elems {
+		if err := setLayoutValue(elem, "", layout, value); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// ByteSize is a number of bytes, it is parsed from the sizes like 512, 4k, 10MiB or 1.5GB.
+// The units are case insensitive, KB, MB... are powers of 1000, and K, KiB, M, MiB... are powers of 1024
+type ByteSize uint64
+
+var byteUnits = map[string]uint64{
+	"": 1, "b": 1,
+	"k": 1 << 10, "kib": 1 << 10, "kb": 1e3,
+	"m": 1 << 20, "mib": 1 << 20, "mb": 1e6,
+	"g": 1 << 30, "gib": 1 << 30, "gb": 1e9,
+	"t": 1 << 40, "tib": 1 << 40, "tb": 1e12,
+	"p": 1 << 50, "pib": 1 << 50, "pb": 1e15,
+	"e": 1 << 60, "eib": 1 << 60, "eb": 1e18,
+}
+
+// Parse the size, such as 10MiB
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+	if len(s) == 0 {
+		return 0, nil
+	}
+
+	i := 0
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+		i++
+	}
+
+	num, unit := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+	mult, ok := byteUnits[unit]
+	if !ok || len(num) == 0 {
+		return 0, fmt.Errorf("invalid byte size (%s)", s)
+	}
+
+	if !strings.Contains(num, ".") {
+		n, err := strconv.ParseUint(num, 10, 64)
+		if err != nil || n > math.MaxUint64/mult {
+			return 0, fmt.Errorf("invalid byte size (%s)", s)
+		}
+		return ByteSize(n * mult), nil
+	}
+
+	f, err := strconv.ParseFloat(num, 64)
+	if f *= float64(mult); err != nil || f >= math.MaxUint64 {
+		return 0, fmt.Errorf("invalid byte size (%s)", s)
+	}
+	return ByteSize(f), nil
+}
+
+func (b *ByteSize) Set(s string) (err error) {
+	*b, err = ParseByteSize(s)
+	return err
+}
+
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	return b.Set(string(text))
+}
+
+// The size in the largest unit that holds it exactly, such as 10MiB or 1500MB
+func (b ByteSize) String() string {
+	units := []string{"EiB", "PiB", "TiB", "GiB", "MiB", "KiB", "EB", "PB", "TB", "GB", "MB", "KB"}
+	for _, unit := range units {
+		if mult := byteUnits[strings.ToLower(unit)]; b != 0 && uint64(b)%mult == 0 {
+			return strconv.FormatUint(uint64(b)/mult, 10) + unit
+		}
+	}
+	return strconv.FormatUint(uint64(b), 10) + "B"
+}
+
+// The elements of the array are filled one by one over the occurrences, the array can not take more than its length
+func (o *optionState) setArrayValue(val string, sep string) error {
+	vals := []string{val}
+	if len(sep) > 0 {
+		vals = strings.Split(val, sep)
+	}
+
+	array := o.settable()
+	for _, v := range vals {
+		if o.arrayLen >= array.Len() {
+			return fmt.Errorf("error: %s takes %d values, but more were provided", o.showName(), array.Len())
+		}
+
+		if err := setBase(v, array.Index(o.arrayLen)); err != nil {
+			return err
+		}
+		o.arrayLen++
+	}
+	return nil
+}
+
+// The arrays that are set must be filled
+func (b *Binder) checkArrays() error {
+	for i := range b.states {
+		o := &b.states[i]
+		if o.Option == nil || o.kind() != reflect.Array || o.arrayLen == 0 || o.arrayLen == o.typ.Len() {
+			continue
+		}
+		return fmt.Errorf("error: %s takes %d values, but %d were provided", o.showName(), o.typ.Len(), o.arrayLen)
+	}
+	return nil
+}
+
+// Set all the elements of the array from one value, such as the default value
+func setArray(val string, sep string, value reflect.Value) error {
+	vals := []string{val}
+	if len(sep) > 0 {
+		vals = strings.Split(val, sep)
+	}
+
+	if len(vals) != value.Len() {
+		return fmt.Errorf("error: the array takes %d values, but %d were provided", value.Len(), len(vals))
+	}
+
+	for i, v := range vals {
+		if err := setBase(v, value.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The element of the slice holds several values, it is not parsed by itself
+func isMultiValue(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8 && !isSelfParsing(t)
+	case reflect.Struct:
+		return !isSelfParsing(t)
+	}
+	return false
+}
+
+// The separator of the elements holding several values, the inner slices and the structures are split by comma without sep
+func multiValueSep(sep string) string {
+	if len(sep) > 0 {
+		return sep
+	}
+	return defaultSep
+}
+
+// Append one element made from the value, the inner slice is split by sep and the structure takes key=value pairs
+func appendMultiValue(val string, sep string, value reflect.Value) error {
+	sep = multiValueSep(sep)
+	elem := reflect.New(value.Type().Elem()).Elem()
+	switch elem.Kind() {
+	case reflect.Slice:
+		//The empty value is an empty inner slice, as Marshal writes it
+		if len(val) == 0 {
+			break
+		}
+		if err := setSepValue(val, sep, elem); err != nil {
+			return err
+		}
+	case reflect.Struct:
+		if strings.HasPrefix(strings.TrimSpace(val), "{") {
+			if err := json.Unmarshal([]byte(val), elem.Addr().Interface()); err != nil {
+				return err
+			}
+			break
+		}
+
+		if err := setStructPairs(val, sep, elem); err != nil {
+			return err
+		}
+	}
+
+	value.Set(reflect.Append(value, elem))
+	return nil
+}
+
+// Set the fields of the structure from the pairs like name=a,addr=b.
+// The key is the field name or its long option name, the case is ignored
+func setStructPairs(val string, sep string, value reflect.Value) error {
+	t := value.Type()
+	for _, pair := range strings.Split(val, sep) {
+		if len(pair) == 0 {
+			continue
+		}
+
+		pos := strings.IndexByte(pair, '=')
+		if pos == -1 {
+			return fmt.Errorf("invalid struct value (%s), want key=value", pair)
+		}
+
+		key := pair[:pos]
+		field := -1
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if sf.PkgPath != "" {
+				continue
+			}
+
+			if name, err := gnuOptionName(sf.Name); strings.EqualFold(sf.Name, key) || err == nil && name == key {
+				field = i
+				break
+			}
+		}
+
+		if field == -1 {
+			return fmt.Errorf("unknown key (%s) of %s", key, t)
+		}
+
+		if err := setBase(pair[pos+1:], indirect(value.Field(field))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package screw
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_ParseByteSize(t *testing.T) {
+	for _, test := range []struct {
+		s    string
+		need ByteSize
+		str  string
+	}{
+		{s: "", need: 0, str: "0B"},
+		{s: "512", need: 512, str: "512B"},
+		{s: "4k", need: 4 << 10, str: "4KiB"},
+		{s: "10MiB", need: 10 << 20, str: "10MiB"},
+		{s: "10 mib", need: 10 << 20, str: "10MiB"},
+		{s: "1.5GB", need: 1500e6, str: "1500MB"},
+		{s: "2KB", need: 2000, str: "2KB"},
+		{s: "1.5k", need: 1536, str: "1536B"},
+		{s: "1e", need: 1 << 60, str: "1EiB"},
+	} {
+		got, err := ParseByteSize(test.s)
+		if err != nil {
+			t.Errorf("%q: %v", test.s, err)
+			continue
+		}
+
+		if got != test.need || got.String() != test.str {
+			t.Errorf("%q: got %d %s, need %d %s", test.s, got, got, test.need, test.str)
+		}
+	}
+
+	for _, s := range []string{"MiB", "1x", "1.2.3k", "-1", "16E", "100000000000000000000"} {
+		if _, err := ParseByteSize(s); err == nil || !strings.Contains(err.Error(), "invalid byte size") {
+			t.Errorf("%q: got %v", s, err)
+		}
+	}
+}
+
+type timeConfig struct {
+	Since   time.Time       `screw:"--since" layout:"2006-01-02" default:"2024-01-01"`
+	Until   time.Time       `screw:"--until"`
+	Days    []time.Time     `screw:"--day" layout:"2006-01-02" sep:","`
+	Cache   ByteSize        `screw:"--cache" default:"64MiB"`
+	Retries []time.Duration `screw:"--retry" sep:"," default:"[\"1s\",\"5s\"]"`
+}
+
+func Test_Time(t *testing.T) {
+	day := func(s string) time.Time {
+		t, _ := time.Parse("2006-01-02", s)
+		return t
+	}
+
+	for _, test := range []struct {
+		args []string
+		need timeConfig
+	}{
+		{
+			args: nil,
+			need: timeConfig{Since: day("2024-01-01"), Cache: 64 << 20, Retries: []time.Duration{time.Second, 5 * time.Second}},
+		},
+		{
+			args: []string{"--since", "2023-05-06", "--until", "2024-01-02T03:04:05Z", "--day", "2024-02-01,2024-02-02", "--cache", "1.5GB", "--retry", "1m,2m"},
+			need: timeConfig{
+				Since:   day("2023-05-06"),
+				Until:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				Days:    []time.Time{day("2024-02-01"), day("2024-02-02")},
+				Cache:   1500e6,
+				Retries: []time.Duration{time.Minute, 2 * time.Minute},
+			},
+		},
+	} {
+		var got timeConfig
+		if err := New(test.args).SetExit(false).Bind(&got); err != nil {
+			t.Fatalf("%q: %v", test.args, err)
+		}
+
+		if !reflect.DeepEqual(got, test.need) {
+			t.Errorf("%q: got %+v, need %+v", test.args, got, test.need)
+		}
+	}
+}
+
+func Test_Time_Error(t *testing.T) {
+	for _, args := range [][]string{
+		{"--since", "2024-01-02T03:04:05Z"},
+		{"--until", "2024-01-02"},
+		{"--cache", "10x"},
+		{"--retry", "1s,x"},
+	} {
+		var got timeConfig
+		if err := New(args).SetExit(false).Bind(&got); err == nil {
+			t.Errorf("%q: need an error", args)
+		}
+	}
+
+	var got struct {
+		Name string `screw:"--name" layout:"2006-01-02"`
+	}
+	if err := New(nil).SetExit(false).Bind(&got); err == nil || !strings.Contains(err.Error(), "the layout tag needs time.Time") {
+		t.Errorf("got %v", err)
+	}
+}